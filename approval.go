@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// privilegedDescriptions explains each privileged option when asking the
+// user for approval.
+var privilegedDescriptions = map[string]string{
+	"docker_conn":      "access to the docker socket",
+	"pid_host":         "the host process namespace",
+	"network_host":     "the host network",
+	"privileged":       "privileged mode",
+	"bootstrap_script": "a bootstrap script from the image that runs the container itself",
+	"extra_args":       "extra docker arguments",
+	"sync_pwd":         "the current directory copied to a host from the manifest",
+}
+
+// describePrivileged explains an option for the approval prompt.  Options
+// that carry a value, like "extra_args: --cap-add=ALL", show it as it is.
+func describePrivileged(option string) string {
+	name, value := splitPrivileged(option)
+	if len(value) > 0 {
+		return fmt.Sprintf("%s (%s)", privilegedDescriptions[name], value)
+	}
+
+	return privilegedDescriptions[name]
+}
+
+// splitPrivileged returns the name of an option and the value it carries,
+// if any.
+func splitPrivileged(option string) (string, string) {
+	parts := strings.SplitN(option, ":", 2)
+	if len(parts) == 1 {
+		return option, ""
+	}
+
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
+// ApprovalRecord is the remembered decision about the privileged options
+// requested by one version of a utility from one manifest.
+type ApprovalRecord struct {
+	Options  []string `yaml:"options"`
+	Approved bool     `yaml:"approved"`
+}
+
+func (sc *StrategyCommon) approvalsPath() (string, error) {
+	holenPath, err := sc.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "approvals.yaml"), nil
+}
+
+func (sc *StrategyCommon) loadApprovals() (map[string]ApprovalRecord, error) {
+	approvals := make(map[string]ApprovalRecord)
+
+	approvalsPath, err := sc.approvalsPath()
+	if err != nil {
+		return approvals, err
+	}
+
+	data, err := ioutil.ReadFile(approvalsPath)
+	if os.IsNotExist(err) {
+		return approvals, nil
+	} else if err != nil {
+		return approvals, errors.Wrap(err, "unable to read approvals")
+	}
+
+	err = yaml.Unmarshal(data, &approvals)
+	if err != nil {
+		return approvals, errors.Wrap(err, "unable to parse approvals")
+	}
+
+	return approvals, nil
+}
+
+func (sc *StrategyCommon) saveApprovals(approvals map[string]ApprovalRecord) error {
+	approvalsPath, err := sc.approvalsPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := yaml.Marshal(approvals)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(approvalsPath, data, 0644)
+}
+
+// approvalKey is what the decision for a version of a utility is remembered
+// by.  It includes the manifest, so that a manifest with the same name from
+// another source doesn't get approval that was given to a different one.
+func (sc *StrategyCommon) approvalKey(name, version string) string {
+	key := fmt.Sprintf("%s--%s", name, version)
+	if len(sc.ManifestPath) == 0 {
+		return key
+	}
+
+	manifestPath, err := filepath.Abs(sc.ManifestPath)
+	if err != nil {
+		manifestPath = sc.ManifestPath
+	}
+	return fmt.Sprintf("%s@%s", key, manifestPath)
+}
+
+// ApprovePrivileged makes sure the user has agreed to run the given version
+// of a utility with the requested privileged options, asking once and
+// remembering the answer.  Options listed in the system level
+// privileged.forbid setting (or all of them, if it's set to "all") are never
+// allowed.
+func (sc *StrategyCommon) ApprovePrivileged(name, version string, options []string) error {
+	if len(options) == 0 {
+		return nil
+	}
+
+	if forbid, err := sc.GetSystem("privileged.forbid"); err == nil && len(forbid) > 0 {
+		forbidden := make(map[string]bool)
+		for _, option := range strings.Split(forbid, ",") {
+			forbidden[strings.TrimSpace(option)] = true
+		}
+
+		for _, option := range options {
+			optionName, _ := splitPrivileged(option)
+			if forbidden["all"] || forbidden[optionName] {
+				sc.Warnf("%s %s needs %s, which is forbidden by system configuration", name, version, option)
+				return &SkipError{fmt.Sprintf("privileged option %s forbidden", optionName)}
+			}
+		}
+	}
+
+	approvals, err := sc.loadApprovals()
+	if err != nil {
+		return err
+	}
+
+	key := sc.approvalKey(name, version)
+	if record, ok := approvals[key]; ok && containsAll(record.Options, options) {
+		if !record.Approved {
+			approvalsPath, _ := sc.approvalsPath()
+			return fmt.Errorf("privileged options for %s %s were refused, to be asked again remove the entry %q from %s", name, version, key, approvalsPath)
+		}
+		return nil
+	}
+
+	descriptions := make([]string, len(options))
+	for i, option := range options {
+		descriptions[i] = describePrivileged(option)
+	}
+
+	approved, err := sc.Confirm(fmt.Sprintf("%s %s wants to run with %s. Allow?", name, version, strings.Join(descriptions, ", ")))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to ask for approval of privileged options for %s %s", name, version))
+	}
+
+	approvals[key] = ApprovalRecord{options, approved}
+	err = sc.saveApprovals(approvals)
+	if err != nil {
+		return errors.Wrap(err, "unable to save approvals")
+	}
+
+	if !approved {
+		return fmt.Errorf("privileged options for %s %s refused", name, version)
+	}
+
+	return nil
+}
+
+func containsAll(have, want []string) bool {
+	present := make(map[string]bool)
+	for _, item := range have {
+		present[item] = true
+	}
+
+	for _, item := range want {
+		if !present[item] {
+			return false
+		}
+	}
+
+	return true
+}
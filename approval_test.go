@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApprovePrivileged(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		modify  func(*TestUtils)
+		options []string
+		prompts int
+		err     string
+		skip    bool
+	}{
+		// nothing to approve
+		{
+			func(tu *TestUtils) {},
+			[]string{},
+			0,
+			"",
+			false,
+		},
+		// user approves
+		{
+			func(tu *TestUtils) {
+				tu.MemSystem.ConfirmAnswer = true
+			},
+			[]string{"docker_conn"},
+			1,
+			"",
+			false,
+		},
+		// user refuses
+		{
+			func(tu *TestUtils) {},
+			[]string{"docker_conn"},
+			1,
+			"refused",
+			false,
+		},
+		// not interactive
+		{
+			func(tu *TestUtils) {
+				tu.MemSystem.ConfirmError = fmt.Errorf("not running interactively")
+			},
+			[]string{"pid_host"},
+			1,
+			"not running interactively",
+			false,
+		},
+		// forbidden by system config
+		{
+			func(tu *TestUtils) {
+				tu.MemConfig.SystemConfig = map[string]string{"privileged.forbid": "pid_host, privileged"}
+			},
+			[]string{"docker_conn", "privileged"},
+			0,
+			"privileged option privileged forbidden",
+			true,
+		},
+		{
+			func(tu *TestUtils) {
+				tu.MemConfig.SystemConfig = map[string]string{"privileged.forbid": "all"}
+			},
+			[]string{"docker_conn"},
+			0,
+			"privileged option docker_conn forbidden",
+			true,
+		},
+		{
+			func(tu *TestUtils) {
+				tu.MemConfig.SystemConfig = map[string]string{"privileged.forbid": "extra_args"}
+			},
+			[]string{"extra_args: --cap-add=ALL"},
+			0,
+			"privileged option extra_args forbidden",
+			true,
+		},
+		// user config can't lift the system level ban
+		{
+			func(tu *TestUtils) {
+				tu.MemConfig.SystemConfig = map[string]string{"privileged.forbid": "all"}
+				tu.MemConfig.UserConfig = map[string]string{"privileged.forbid": ""}
+			},
+			[]string{"docker_conn"},
+			0,
+			"privileged option docker_conn forbidden",
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		tempdir, _ := ioutil.TempDir("", "holen")
+		defer os.RemoveAll(tempdir)
+
+		tu, td := newDockerStrategy()
+		tu.MemSystem.Setenv("HOME", tempdir)
+		test.modify(tu)
+
+		err := td.ApprovePrivileged("testdocker", "1.9", test.options)
+		if len(test.err) > 0 {
+			assert.NotNil(err)
+			assert.Contains(err.Error(), test.err)
+			_, isSkip := err.(*SkipError)
+			assert.Equal(test.skip, isSkip)
+		} else {
+			assert.Nil(err)
+		}
+		assert.Len(tu.MemSystem.Prompts, test.prompts)
+	}
+}
+
+func TestApprovePrivilegedRemembered(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, td := newDockerStrategy()
+	tu.MemSystem.Setenv("HOME", tempdir)
+
+	// approval is remembered
+	tu.MemSystem.ConfirmAnswer = true
+	assert.Nil(td.ApprovePrivileged("testdocker", "1.9", []string{"docker_conn", "pid_host"}))
+	assert.Nil(td.ApprovePrivileged("testdocker", "1.9", []string{"pid_host"}))
+	assert.Len(tu.MemSystem.Prompts, 1)
+
+	// asking for more than was approved prompts again
+	tu.MemSystem.ConfirmAnswer = false
+	assert.NotNil(td.ApprovePrivileged("testdocker", "1.9", []string{"privileged"}))
+	assert.Len(tu.MemSystem.Prompts, 2)
+
+	// refusal is remembered too
+	err := td.ApprovePrivileged("testdocker", "1.9", []string{"privileged"})
+	assert.NotNil(err)
+	assert.Equal(fmt.Sprintf(`privileged options for testdocker 1.9 were refused, to be asked again remove the entry "testdocker--1.9" from %s/.local/share/holen/approvals.yaml`, tempdir), err.Error())
+	assert.Len(tu.MemSystem.Prompts, 2)
+
+	// approvals are per version
+	tu.MemSystem.ConfirmAnswer = true
+	assert.Nil(td.ApprovePrivileged("testdocker", "2.0", []string{"pid_host"}))
+	assert.Len(tu.MemSystem.Prompts, 3)
+
+	// and per manifest, so one with the same name from another source
+	// doesn't get the approval
+	td.ManifestPath = "/sources/main/testdocker.yaml"
+	assert.Nil(td.ApprovePrivileged("testdocker", "2.0", []string{"pid_host"}))
+	assert.Nil(td.ApprovePrivileged("testdocker", "2.0", []string{"pid_host"}))
+	assert.Len(tu.MemSystem.Prompts, 4)
+
+	td.ManifestPath = "/sources/other/testdocker.yaml"
+	tu.MemSystem.ConfirmAnswer = false
+	err = td.ApprovePrivileged("testdocker", "2.0", []string{"pid_host"})
+	assert.Len(tu.MemSystem.Prompts, 5)
+	err = td.ApprovePrivileged("testdocker", "2.0", []string{"pid_host"})
+	assert.Contains(err.Error(), `remove the entry "testdocker--2.0@/sources/other/testdocker.yaml" from`)
+	assert.Len(tu.MemSystem.Prompts, 5)
+}
+
+func TestApprovePrivilegedExtraArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, td := newDockerStrategy()
+	tu.MemSystem.Setenv("HOME", tempdir)
+	tu.MemSystem.ConfirmAnswer = true
+	td.Data.ExtraArgs = []string{"-v", "/:/host"}
+
+	// the prompt shows the arguments as they are
+	assert.Nil(td.ApprovePrivileged("testdocker", "1.9", td.PrivilegedOptions()))
+	assert.Len(tu.MemSystem.Prompts, 1)
+	assert.Contains(tu.MemSystem.Prompts[0], "extra docker arguments (-v /:/host)")
+
+	// different arguments in the manifest need approving again
+	td.Data.ExtraArgs = []string{"-v", "/:/host", "--cap-add=ALL"}
+	assert.Nil(td.ApprovePrivileged("testdocker", "1.9", td.PrivilegedOptions()))
+	assert.Len(tu.MemSystem.Prompts, 2)
+}
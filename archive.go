@@ -0,0 +1,575 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+)
+
+// ArchiveOptions change how an archive is unpacked.
+type ArchiveOptions struct {
+	// Format is used instead of detecting the format, e.g. "tar.gz".
+	Format string
+
+	// StripComponents is the number of leading directories removed from
+	// the path of every entry.
+	StripComponents int
+
+	// MaxSize caps the total size of everything unpacked, in bytes.  Zero
+	// means defaultMaxArchiveSize.
+	MaxSize int64
+}
+
+// defaultMaxArchiveSize is the most that an archive is allowed to unpack to
+// unless archive.max_size says otherwise.
+const defaultMaxArchiveSize = 4 << 30
+
+// archiveFormats lists the formats that can be unpacked, with the file
+// extensions that they're known by.
+var archiveFormats = []struct {
+	format     string
+	extensions []string
+}{
+	{"tar.gz", []string{".tar.gz", ".tgz"}},
+	{"tar.bz2", []string{".tar.bz2", ".tbz2", ".tbz"}},
+	{"tar.xz", []string{".tar.xz", ".txz"}},
+	{"tar.zst", []string{".tar.zst", ".tzst"}},
+	{"tar", []string{".tar"}},
+	{"zip", []string{".zip"}},
+	{"gz", []string{".gz"}},
+	{"bz2", []string{".bz2"}},
+	{"xz", []string{".xz"}},
+	{"zst", []string{".zst"}},
+}
+
+// compressionMagic maps the bytes that compressed files start with to the
+// compression used.
+var compressionMagic = []struct {
+	compression string
+	magic       []byte
+}{
+	{"gz", []byte{0x1f, 0x8b}},
+	{"bz2", []byte("BZh")},
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{"zst", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// singleFileFormat reports whether the format holds a single compressed
+// file rather than a tree of them.
+func singleFileFormat(format string) bool {
+	return format == "gz" || format == "bz2" || format == "xz" || format == "zst"
+}
+
+// formatFromName guesses the format from the archive's file name.
+func formatFromName(name string) string {
+	lower := strings.ToLower(name)
+	for _, candidate := range archiveFormats {
+		for _, extension := range candidate.extensions {
+			if strings.HasSuffix(lower, extension) {
+				return candidate.format
+			}
+		}
+	}
+
+	return ""
+}
+
+// validFormat checks a format given in a manifest, accepting the short
+// names too (like "tgz").
+func validFormat(format string) (string, error) {
+	for _, candidate := range archiveFormats {
+		if format == candidate.format {
+			return format, nil
+		}
+		for _, extension := range candidate.extensions {
+			if format == strings.TrimPrefix(extension, ".") {
+				return candidate.format, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("unknown archive format %s", format)
+}
+
+// isTar checks for the magic that tar headers have.
+func isTar(header []byte) bool {
+	return len(header) >= 262 && bytes.Equal(header[257:262], []byte("ustar"))
+}
+
+// archiveFormat works out the format of archive from its contents, falling
+// back to its file name for files that don't say, like old style tars.
+// An override skips all of that.
+func archiveFormat(archive, override string) (string, error) {
+	if len(override) > 0 {
+		return validFormat(override)
+	}
+
+	byName := formatFromName(archive)
+
+	file, err := os.Open(archive)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	header := make([]byte, 512)
+	n, _ := io.ReadFull(file, header)
+	header = header[:n]
+
+	if bytes.HasPrefix(header, []byte("PK\x03\x04")) || bytes.HasPrefix(header, []byte("PK\x05\x06")) {
+		return "zip", nil
+	}
+	if isTar(header) {
+		return "tar", nil
+	}
+
+	for _, candidate := range compressionMagic {
+		if !bytes.HasPrefix(header, candidate.magic) {
+			continue
+		}
+
+		_, err = file.Seek(0, io.SeekStart)
+		if err != nil {
+			return "", err
+		}
+		reader, err := decompressor(candidate.compression, file)
+		if err != nil {
+			return "", err
+		}
+		defer reader.Close()
+
+		inner := make([]byte, 512)
+		n, _ := io.ReadFull(reader, inner)
+		if isTar(inner[:n]) || byName == fmt.Sprintf("tar.%s", candidate.compression) {
+			return fmt.Sprintf("tar.%s", candidate.compression), nil
+		}
+		return candidate.compression, nil
+	}
+
+	if len(byName) > 0 {
+		return byName, nil
+	}
+
+	return "", fmt.Errorf("unable to detect archive format of %s", filepath.Base(archive))
+}
+
+// decompressor wraps reader with the decompression for compression.
+func decompressor(compression string, reader io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case "gz":
+		return gzip.NewReader(reader)
+	case "bz2":
+		return io.NopCloser(bzip2.NewReader(reader)), nil
+	case "xz":
+		xzReader, err := xz.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(xzReader), nil
+	case "zst":
+		zstReader, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		return zstReader.IOReadCloser(), nil
+	}
+
+	return nil, fmt.Errorf("unknown compression %s", compression)
+}
+
+// unpackArchive unpacks archive into destPath.  Single compressed files are
+// written to destPath itself.
+func unpackArchive(archive, destPath string, options ArchiveOptions) error {
+	format, err := archiveFormat(archive, options.Format)
+	if err != nil {
+		return err
+	}
+
+	u := newUnpacker(destPath, options)
+
+	if format == "zip" {
+		return u.unzip(archive)
+	}
+
+	file, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == "tar" {
+		return u.untar(file)
+	}
+
+	compression := strings.TrimPrefix(format, "tar.")
+	reader, err := decompressor(compression, file)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to read %s", format))
+	}
+	defer reader.Close()
+
+	if singleFileFormat(format) {
+		return u.writeFile(filepath.Base(destPath), destPath, reader, 0644)
+	}
+
+	return u.untar(reader)
+}
+
+// unpacker writes the entries of an archive under destPath, refusing any
+// that would end up outside of it.
+type unpacker struct {
+	destPath string
+	strip    int
+	maxSize  int64
+	written  int64
+	symlinks []pendingSymlink
+}
+
+// pendingSymlink is a symlink that's made once everything else is unpacked,
+// so that no entry is ever written through one.
+type pendingSymlink struct {
+	name     string
+	target   string
+	linkname string
+}
+
+func newUnpacker(destPath string, options ArchiveOptions) *unpacker {
+	maxSize := options.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxArchiveSize
+	}
+
+	return &unpacker{
+		destPath: filepath.Clean(destPath),
+		strip:    options.StripComponents,
+		maxSize:  maxSize,
+	}
+}
+
+// UnsafeEntryError is returned for an archive entry that's refused because
+// unpacking it could write outside the destination or misuse the system.
+type UnsafeEntryError struct {
+	Name   string
+	Reason string
+}
+
+func (e *UnsafeEntryError) Error() string {
+	return fmt.Sprintf("refusing to unpack %s: %s", e.Name, e.Reason)
+}
+
+func refuse(name, reason string) error {
+	return &UnsafeEntryError{name, reason}
+}
+
+// entryError adds the entry name to err, unless it's already there.
+func entryError(name string, err error) error {
+	if _, unsafe := err.(*UnsafeEntryError); unsafe {
+		return err
+	}
+	return errors.Wrap(err, fmt.Sprintf("unable to unpack %s", name))
+}
+
+// inside reports whether path is destPath or somewhere under it.
+func (u *unpacker) inside(path string) bool {
+	return path == u.destPath || strings.HasPrefix(path, u.destPath+string(filepath.Separator))
+}
+
+// entryPath returns where an entry is unpacked to, with the leading
+// directories stripped off.  An empty path means the entry is skipped.
+func (u *unpacker) entryPath(name string) (string, error) {
+	slashed := filepath.ToSlash(name)
+	if strings.HasPrefix(slashed, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", refuse(name, "absolute path")
+	}
+
+	parts := strings.Split(strings.Trim(slashed, "/"), "/")
+	for _, part := range parts {
+		if part == ".." {
+			return "", refuse(name, "path escapes the destination")
+		}
+	}
+	if len(parts) <= u.strip {
+		return "", nil
+	}
+
+	stripped := strings.Join(parts[u.strip:], "/")
+	if stripped == "." || len(stripped) == 0 {
+		return "", nil
+	}
+
+	target := filepath.Join(u.destPath, filepath.FromSlash(stripped))
+	if !u.inside(target) {
+		return "", refuse(name, "path escapes the destination")
+	}
+
+	return target, nil
+}
+
+func (u *unpacker) untar(reader io.Reader) error {
+	err := os.MkdirAll(u.destPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return u.finish()
+		}
+		if err != nil {
+			return errors.Wrap(err, "unable to read tar")
+		}
+
+		switch header.Typeflag {
+		case tar.TypeChar, tar.TypeBlock:
+			return refuse(header.Name, "device file")
+		case tar.TypeFifo:
+			return refuse(header.Name, "named pipe")
+		}
+
+		target, err := u.entryPath(header.Name)
+		if err != nil {
+			return err
+		}
+		if len(target) == 0 {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = u.writeFile(header.Name, target, tarReader, header.FileInfo().Mode())
+		case tar.TypeSymlink:
+			err = u.symlink(header.Name, target, header.Linkname)
+		case tar.TypeLink:
+			err = u.hardlink(header.Name, target, header.Linkname)
+		}
+		if err != nil {
+			return entryError(header.Name, err)
+		}
+	}
+}
+
+func (u *unpacker) unzip(archive string) error {
+	zipReader, err := zip.OpenReader(archive)
+	if err != nil {
+		return errors.Wrap(err, "unable to read zip")
+	}
+	defer zipReader.Close()
+
+	err = os.MkdirAll(u.destPath, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range zipReader.File {
+		mode := file.Mode()
+		if mode&os.ModeDevice != 0 || mode&os.ModeCharDevice != 0 {
+			return refuse(file.Name, "device file")
+		}
+		if mode&os.ModeNamedPipe != 0 || mode&os.ModeSocket != 0 {
+			return refuse(file.Name, "named pipe")
+		}
+
+		target, err := u.entryPath(file.Name)
+		if err != nil {
+			return err
+		}
+		if len(target) == 0 {
+			continue
+		}
+
+		err = u.unzipFile(file, target)
+		if err != nil {
+			return entryError(file.Name, err)
+		}
+	}
+
+	return u.finish()
+}
+
+func (u *unpacker) unzipFile(file *zip.File, target string) error {
+	mode := file.Mode()
+	if mode.IsDir() {
+		return os.MkdirAll(target, 0755)
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if mode&os.ModeSymlink != 0 {
+		linkname, err := io.ReadAll(io.LimitReader(reader, 4096))
+		if err != nil {
+			return err
+		}
+		return u.symlink(file.Name, target, string(linkname))
+	}
+
+	return u.writeFile(file.Name, target, reader, mode)
+}
+
+// writeFile copies the entry to target, keeping track of how much has been
+// unpacked so that an archive can't fill up the disk.
+func (u *unpacker) writeFile(name, target string, reader io.Reader, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	remaining := u.maxSize - u.written
+	written, err := io.Copy(file, io.LimitReader(reader, remaining+1))
+	u.written += written
+	if err != nil {
+		return err
+	}
+	if written > remaining {
+		return refuse(name, fmt.Sprintf("archive unpacks to more than %d bytes", u.maxSize))
+	}
+
+	return nil
+}
+
+// symlink checks where a symlink points and queues it up to be made at the
+// end.
+func (u *unpacker) symlink(name, target, linkname string) error {
+	if filepath.IsAbs(linkname) || strings.HasPrefix(filepath.ToSlash(linkname), "/") {
+		return refuse(name, fmt.Sprintf("symlink to absolute path %s", linkname))
+	}
+	if !u.inside(filepath.Join(filepath.Dir(target), linkname)) {
+		return refuse(name, fmt.Sprintf("symlink to %s escapes the destination", linkname))
+	}
+
+	u.symlinks = append(u.symlinks, pendingSymlink{name, target, linkname})
+	return nil
+}
+
+func (u *unpacker) hardlink(name, target, linkname string) error {
+	linkTarget, err := u.entryPath(linkname)
+	if err != nil {
+		return refuse(name, fmt.Sprintf("hard link to %s escapes the destination", linkname))
+	}
+	if len(linkTarget) == 0 {
+		return fmt.Errorf("hard link %s points to stripped entry %s", name, linkname)
+	}
+
+	err = os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+
+	os.Remove(target)
+	return os.Link(linkTarget, target)
+}
+
+// finish makes the queued symlinks.  Each one is made in its parent as
+// reached through the links already made, and every link made so far is
+// followed again afterwards, so that links can't be chained to lead outside
+// of the destination.  A link that lets any of them escape is removed again.
+func (u *unpacker) finish() error {
+	made := []pendingSymlink{}
+	for _, link := range u.symlinks {
+		parent, err := u.resolve(filepath.Dir(link.target))
+		if err != nil {
+			return entryError(link.name, err)
+		}
+		if !u.inside(parent) {
+			return refuse(link.name, "path escapes the destination")
+		}
+
+		err = os.MkdirAll(parent, 0755)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(parent, filepath.Base(link.target))
+		os.Remove(target)
+		err = os.Symlink(link.linkname, target)
+		if err != nil {
+			return entryError(link.name, err)
+		}
+
+		made = append(made, pendingSymlink{link.name, target, link.linkname})
+		for _, check := range made {
+			resolved, err := u.resolve(check.target)
+			if err == nil && u.inside(resolved) {
+				continue
+			}
+
+			os.Remove(target)
+			if err != nil {
+				return entryError(check.name, err)
+			}
+			return refuse(check.name, fmt.Sprintf("symlink to %s escapes the destination", check.linkname))
+		}
+	}
+
+	return nil
+}
+
+// resolve follows symlinks in path one component at a time, like the
+// kernel would, and returns where it ends up.  Anything past a missing
+// component is taken as it is.
+func (u *unpacker) resolve(path string) (string, error) {
+	rel, err := filepath.Rel(u.destPath, path)
+	if err != nil {
+		return "", err
+	}
+
+	current := u.destPath
+	remaining := strings.Split(rel, string(filepath.Separator))
+	for hops := 0; len(remaining) > 0; {
+		part := remaining[0]
+		remaining = remaining[1:]
+
+		next := filepath.Join(current, part)
+		if !u.inside(next) {
+			return next, nil
+		}
+
+		info, err := os.Lstat(next)
+		if err != nil {
+			return filepath.Join(append([]string{next}, remaining...)...), nil
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			current = next
+			continue
+		}
+
+		hops++
+		if hops > 255 {
+			return "", fmt.Errorf("too many levels of symbolic links")
+		}
+		linkname, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(linkname) {
+			return linkname, nil
+		}
+		remaining = append(strings.Split(linkname, string(filepath.Separator)), remaining...)
+	}
+
+	return current, nil
+}
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/ulikunitz/xz"
+)
+
+type testEntry struct {
+	name     string
+	body     string
+	linkname string
+	typeflag byte
+}
+
+var testEntries = []testEntry{
+	{name: "tool-1.0/", typeflag: tar.TypeDir},
+	{name: "tool-1.0/bin/tool", body: "#!/bin/sh\n", typeflag: tar.TypeReg},
+	{name: "tool-1.0/share/tool.txt", body: "data\n", typeflag: tar.TypeReg},
+	{name: "tool-1.0/bin/tool-link", linkname: "tool", typeflag: tar.TypeSymlink},
+}
+
+func makeTar(entries []testEntry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, entry := range entries {
+		mode := int64(0644)
+		if entry.typeflag == tar.TypeDir || strings.Contains(entry.name, "bin/") {
+			mode = 0755
+		}
+		tw.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Linkname: entry.linkname,
+			Typeflag: entry.typeflag,
+			Mode:     mode,
+			Size:     int64(len(entry.body)),
+		})
+		tw.Write([]byte(entry.body))
+	}
+	tw.Close()
+	return buf.Bytes()
+}
+
+func makeZip(entries []testEntry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		switch entry.typeflag {
+		case tar.TypeDir:
+			header.SetMode(os.ModeDir | 0755)
+		case tar.TypeSymlink:
+			header.SetMode(os.ModeSymlink | 0777)
+		default:
+			header.SetMode(0755)
+		}
+		w, _ := zw.CreateHeader(header)
+		if entry.typeflag == tar.TypeSymlink {
+			w.Write([]byte(entry.linkname))
+		} else {
+			w.Write([]byte(entry.body))
+		}
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func compress(compression string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch compression {
+	case "gz":
+		w = gzip.NewWriter(&buf)
+	case "xz":
+		w, _ = xz.NewWriter(&buf)
+	case "zst":
+		w, _ = zstd.NewWriter(&buf)
+	}
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// listTree returns every path under root, with links marked by their target.
+func listTree(root string) []string {
+	paths := []string{}
+	var walk func(string)
+	walk = func(dir string) {
+		entries, _ := ioutil.ReadDir(dir)
+		for _, entry := range entries {
+			full := path.Join(dir, entry.Name())
+			rel := strings.TrimPrefix(full, root+"/")
+			if entry.Mode()&os.ModeSymlink != 0 {
+				target, _ := os.Readlink(full)
+				paths = append(paths, rel+" -> "+target)
+			} else if entry.IsDir() {
+				paths = append(paths, rel+"/")
+				walk(full)
+			} else {
+				paths = append(paths, rel)
+			}
+		}
+	}
+	walk(root)
+	sort.Strings(paths)
+	return paths
+}
+
+func TestArchiveFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(tempdir)
+
+	tarData := makeTar(testEntries)
+
+	var formatTests = []struct {
+		name     string
+		data     []byte
+		override string
+		format   string
+		err      string
+	}{
+		{"tool.tar.gz", compress("gz", tarData), "", "tar.gz", ""},
+		{"download", compress("gz", tarData), "", "tar.gz", ""},
+		{"download", compress("xz", tarData), "", "tar.xz", ""},
+		{"download", compress("zst", tarData), "", "tar.zst", ""},
+		{"download", tarData, "", "tar", ""},
+		{"download", makeZip(testEntries), "", "zip", ""},
+		{"tool.gz", compress("gz", []byte("binary")), "", "gz", ""},
+		{"tool", compress("xz", []byte("binary")), "", "xz", ""},
+		{"tool", compress("zst", []byte("binary")), "", "zst", ""},
+		{"tool.tgz", []byte{}, "", "tar.gz", ""},
+		{"tool.tar.bz2", []byte{}, "", "tar.bz2", ""},
+		{"tool.txz", []byte{}, "", "tar.xz", ""},
+		{"tool.tar.zst", []byte{}, "", "tar.zst", ""},
+		{"tool.xz", []byte{}, "", "xz", ""},
+		{"download", []byte{}, "tgz", "tar.gz", ""},
+		{"tool.zip", compress("gz", tarData), "tar.zst", "tar.zst", ""},
+		{"download", []byte("plain text"), "", "", "unable to detect archive format of download"},
+		{"download", []byte{}, "rar", "", "unknown archive format rar"},
+	}
+
+	for _, test := range formatTests {
+		archivePath := path.Join(tempdir, test.name)
+		ioutil.WriteFile(archivePath, test.data, 0644)
+
+		format, err := archiveFormat(archivePath, test.override)
+		if len(test.err) > 0 {
+			assert.NotNil(err)
+			assert.Equal(test.err, err.Error())
+		} else {
+			assert.Nil(err)
+			assert.Equal(test.format, format, test.name)
+		}
+		os.Remove(archivePath)
+	}
+}
+
+func TestUnpackArchive(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(tempdir)
+
+	tarData := makeTar(testEntries)
+	fullTree := []string{
+		"tool-1.0/",
+		"tool-1.0/bin/",
+		"tool-1.0/bin/tool",
+		"tool-1.0/bin/tool-link -> tool",
+		"tool-1.0/share/",
+		"tool-1.0/share/tool.txt",
+	}
+	strippedTree := []string{
+		"bin/",
+		"bin/tool",
+		"bin/tool-link -> tool",
+		"share/",
+		"share/tool.txt",
+	}
+
+	var unpackTests = []struct {
+		data    []byte
+		options ArchiveOptions
+		tree    []string
+	}{
+		{tarData, ArchiveOptions{}, fullTree},
+		{compress("gz", tarData), ArchiveOptions{}, fullTree},
+		{compress("xz", tarData), ArchiveOptions{}, fullTree},
+		{compress("zst", tarData), ArchiveOptions{}, fullTree},
+		{makeZip(testEntries), ArchiveOptions{}, fullTree},
+		{compress("zst", tarData), ArchiveOptions{StripComponents: 1}, strippedTree},
+		{makeZip(testEntries), ArchiveOptions{StripComponents: 1}, strippedTree},
+		{compress("gz", tarData), ArchiveOptions{StripComponents: 2}, []string{"tool", "tool-link -> tool", "tool.txt"}},
+		{compress("gz", tarData), ArchiveOptions{StripComponents: 3}, []string{}},
+	}
+
+	for _, test := range unpackTests {
+		archivePath := path.Join(tempdir, "download")
+		destPath := path.Join(tempdir, "unpacked")
+		ioutil.WriteFile(archivePath, test.data, 0644)
+
+		assert.Nil(unpackArchive(archivePath, destPath, test.options))
+		assert.Equal(test.tree, listTree(destPath))
+
+		os.RemoveAll(destPath)
+	}
+
+	// permissions are kept
+	archivePath := path.Join(tempdir, "download")
+	destPath := path.Join(tempdir, "unpacked")
+	ioutil.WriteFile(archivePath, compress("gz", tarData), 0644)
+	assert.Nil(unpackArchive(archivePath, destPath, ArchiveOptions{}))
+	info, err := os.Stat(path.Join(destPath, "tool-1.0/bin/tool"))
+	assert.Nil(err)
+	assert.Equal(os.FileMode(0755), info.Mode().Perm())
+}
+
+func TestUnpackSingleFile(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(tempdir)
+
+	for _, compression := range []string{"gz", "xz", "zst"} {
+		archivePath := path.Join(tempdir, "tool."+compression)
+		destPath := path.Join(tempdir, "tool")
+		ioutil.WriteFile(archivePath, compress(compression, []byte("binary contents")), 0644)
+
+		assert.Nil(unpackArchive(archivePath, destPath, ArchiveOptions{}))
+		contents, err := ioutil.ReadFile(destPath)
+		assert.Nil(err)
+		assert.Equal("binary contents", string(contents))
+
+		os.Remove(destPath)
+	}
+}
+
+func TestUnpackUnsafe(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(tempdir)
+
+	var unsafeTests = []struct {
+		entries []testEntry
+		options ArchiveOptions
+		err     string
+	}{
+		{
+			[]testEntry{{name: "../evil", body: "x", typeflag: tar.TypeReg}},
+			ArchiveOptions{},
+			"refusing to unpack ../evil: path escapes the destination",
+		},
+		{
+			[]testEntry{{name: "tool/../../evil", body: "x", typeflag: tar.TypeReg}},
+			ArchiveOptions{StripComponents: 1},
+			"refusing to unpack tool/../../evil: path escapes the destination",
+		},
+		{
+			[]testEntry{{name: "/tmp/evil", body: "x", typeflag: tar.TypeReg}},
+			ArchiveOptions{},
+			"refusing to unpack /tmp/evil: absolute path",
+		},
+		{
+			[]testEntry{{name: "passwd", linkname: "/etc/passwd", typeflag: tar.TypeSymlink}},
+			ArchiveOptions{},
+			"refusing to unpack passwd: symlink to absolute path /etc/passwd",
+		},
+		{
+			[]testEntry{{name: "bin/up", linkname: "../../evil", typeflag: tar.TypeSymlink}},
+			ArchiveOptions{},
+			"refusing to unpack bin/up: symlink to ../../evil escapes the destination",
+		},
+		{
+			[]testEntry{
+				{name: "a/b/c", linkname: "../..", typeflag: tar.TypeSymlink},
+				{name: "escape", linkname: "a/b/c/../evil", typeflag: tar.TypeSymlink},
+			},
+			ArchiveOptions{},
+			"refusing to unpack escape: symlink to a/b/c/../evil escapes the destination",
+		},
+		{
+			[]testEntry{
+				{name: "up", linkname: ".", typeflag: tar.TypeSymlink},
+				{name: "up/../evil", body: "x", typeflag: tar.TypeReg},
+			},
+			ArchiveOptions{},
+			"refusing to unpack up/../evil: path escapes the destination",
+		},
+		{
+			[]testEntry{{name: "shadow", linkname: "../etc/shadow", typeflag: tar.TypeLink}},
+			ArchiveOptions{},
+			"refusing to unpack shadow: hard link to ../etc/shadow escapes the destination",
+		},
+		{
+			[]testEntry{{name: "dev/sda", typeflag: tar.TypeBlock}},
+			ArchiveOptions{},
+			"refusing to unpack dev/sda: device file",
+		},
+		{
+			[]testEntry{{name: "dev/null", typeflag: tar.TypeChar}},
+			ArchiveOptions{},
+			"refusing to unpack dev/null: device file",
+		},
+		{
+			[]testEntry{{name: "pipe", typeflag: tar.TypeFifo}},
+			ArchiveOptions{},
+			"refusing to unpack pipe: named pipe",
+		},
+		{
+			[]testEntry{
+				{name: "small", body: "12345", typeflag: tar.TypeReg},
+				{name: "big", body: "1234567890", typeflag: tar.TypeReg},
+			},
+			ArchiveOptions{MaxSize: 12},
+			"refusing to unpack big: archive unpacks to more than 12 bytes",
+		},
+	}
+
+	for _, test := range unsafeTests {
+		archivePath := path.Join(tempdir, "download.tar.gz")
+		destPath := path.Join(tempdir, "dest", "unpacked")
+		ioutil.WriteFile(archivePath, compress("gz", makeTar(test.entries)), 0644)
+
+		err := unpackArchive(archivePath, destPath, test.options)
+		assert.NotNil(err)
+		if err != nil {
+			assert.Equal(test.err, err.Error())
+		}
+
+		_, err = os.Lstat(path.Join(tempdir, "evil"))
+		assert.True(os.IsNotExist(err))
+		_, err = os.Lstat(path.Join(tempdir, "dest", "evil"))
+		assert.True(os.IsNotExist(err))
+
+		os.RemoveAll(path.Join(tempdir, "dest"))
+	}
+}
+
+func TestUnpackChainedLinks(t *testing.T) {
+	assert := assert.New(t)
+
+	var chainTests = []struct {
+		entries []testEntry
+		err     string
+	}{
+		{
+			[]testEntry{
+				{name: "s", linkname: ".", typeflag: tar.TypeSymlink},
+				{name: "x", linkname: "s/..", typeflag: tar.TypeSymlink},
+				{name: "y", linkname: "x/..", typeflag: tar.TypeSymlink},
+				{name: "y/planted", linkname: "pwned", typeflag: tar.TypeSymlink},
+			},
+			"refusing to unpack x: symlink to s/.. escapes the destination",
+		},
+		// a link that only escapes once a later one is made
+		{
+			[]testEntry{
+				{name: "b", linkname: "c/..", typeflag: tar.TypeSymlink},
+				{name: "c", linkname: ".", typeflag: tar.TypeSymlink},
+				{name: "b/planted", linkname: "pwned", typeflag: tar.TypeSymlink},
+			},
+			"refusing to unpack b: symlink to c/.. escapes the destination",
+		},
+	}
+
+	for _, test := range chainTests {
+		tempdir, _ := ioutil.TempDir("", "archive")
+		defer os.RemoveAll(tempdir)
+
+		archivePath := path.Join(tempdir, "download.tar")
+		destPath := path.Join(tempdir, "dest", "unpacked")
+		ioutil.WriteFile(archivePath, makeTar(test.entries), 0644)
+
+		err := unpackArchive(archivePath, destPath, ArchiveOptions{})
+		assert.NotNil(err)
+		if err != nil {
+			assert.Equal(test.err, err.Error())
+		}
+
+		// nothing was made outside of the destination
+		assert.Equal([]string{"dest/", "dest/unpacked/", "download.tar"}, filterTree(listTree(tempdir), "dest/unpacked/"))
+	}
+}
+
+// filterTree leaves out the paths under prefix.
+func filterTree(paths []string, prefix string) []string {
+	filtered := []string{}
+	for _, p := range paths {
+		if p == prefix || !strings.HasPrefix(p, prefix) {
+			filtered = append(filtered, p)
+		}
+	}
+
+	return filtered
+}
+
+func TestUnzipUnsafe(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(tempdir)
+
+	var unsafeTests = []struct {
+		entries []testEntry
+		err     string
+	}{
+		{
+			[]testEntry{{name: "../evil", body: "x", typeflag: tar.TypeReg}},
+			"refusing to unpack ../evil: path escapes the destination",
+		},
+		{
+			[]testEntry{{name: "bin/up", linkname: "../../evil", typeflag: tar.TypeSymlink}},
+			"refusing to unpack bin/up: symlink to ../../evil escapes the destination",
+		},
+	}
+
+	for _, test := range unsafeTests {
+		archivePath := path.Join(tempdir, "download.zip")
+		destPath := path.Join(tempdir, "dest", "unpacked")
+		ioutil.WriteFile(archivePath, makeZip(test.entries), 0644)
+
+		err := unpackArchive(archivePath, destPath, ArchiveOptions{})
+		assert.NotNil(err)
+		if err != nil {
+			assert.Equal(test.err, err.Error())
+		}
+
+		os.RemoveAll(path.Join(tempdir, "dest"))
+	}
+}
+
+func TestUnpackSafeLinks(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(tempdir)
+
+	entries := []testEntry{
+		{name: "lib/libtool.so.1", body: "lib", typeflag: tar.TypeReg},
+		{name: "lib/libtool.so", linkname: "libtool.so.1", typeflag: tar.TypeSymlink},
+		{name: "lib64", linkname: "lib", typeflag: tar.TypeSymlink},
+		{name: "bin/tool", body: "tool", typeflag: tar.TypeReg},
+		{name: "bin/tool-hard", linkname: "bin/tool", typeflag: tar.TypeLink},
+		{name: "bin/lib", linkname: "../lib64/libtool.so", typeflag: tar.TypeSymlink},
+	}
+
+	archivePath := path.Join(tempdir, "download.tar")
+	destPath := path.Join(tempdir, "unpacked")
+	ioutil.WriteFile(archivePath, makeTar(entries), 0644)
+
+	assert.Nil(unpackArchive(archivePath, destPath, ArchiveOptions{}))
+	assert.Equal([]string{
+		"bin/",
+		"bin/lib -> ../lib64/libtool.so",
+		"bin/tool",
+		"bin/tool-hard",
+		"lib/",
+		"lib/libtool.so -> libtool.so.1",
+		"lib/libtool.so.1",
+		"lib64 -> lib",
+	}, listTree(destPath))
+
+	contents, err := ioutil.ReadFile(path.Join(destPath, "bin/lib"))
+	assert.Nil(err)
+	assert.Equal("lib", string(contents))
+}
+
+func TestUnpackSingleFileTooBig(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "archive")
+	defer os.RemoveAll(tempdir)
+
+	archivePath := path.Join(tempdir, "tool.zst")
+	ioutil.WriteFile(archivePath, compress("zst", []byte(strings.Repeat("x", 1000))), 0644)
+
+	err := unpackArchive(archivePath, path.Join(tempdir, "tool"), ArchiveOptions{MaxSize: 100})
+	assert.NotNil(err)
+	assert.Equal("refusing to unpack tool: archive unpacks to more than 100 bytes", err.Error())
+}
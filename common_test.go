@@ -24,6 +24,13 @@ func (mc *MemConfig) Get(key string) (string, error) {
 	return "", nil
 }
 
+func (mc *MemConfig) GetSystem(key string) (string, error) {
+	if val, ok := mc.SystemConfig[key]; ok {
+		return val, nil
+	}
+	return "", nil
+}
+
 func (mc *MemConfig) Unset(system bool, key string) error {
 	if system {
 		delete(mc.SystemConfig, key)
@@ -88,6 +95,10 @@ type MemRunner struct {
 	FailCheckCmds     map[string]bool
 	FailCmds          map[string]error
 	CommandOutputCmds map[string]string
+	Outputs           map[string]string
+	Effects           map[string]func()
+	HistoryDir        map[string]string
+	Inputs            map[string]string
 }
 
 func (mr *MemRunner) CheckCommand(command string, args []string) bool {
@@ -104,6 +115,10 @@ func (mr *MemRunner) RunCommand(command string, args []string) error {
 	fullCommand := strings.Join(append([]string{command}, args...), " ")
 	mr.History = append(mr.History, fullCommand)
 
+	if effect, ok := mr.Effects[fullCommand]; ok {
+		effect()
+	}
+
 	e, ok := mr.FailCmds[fullCommand]
 
 	if !ok {
@@ -113,6 +128,14 @@ func (mr *MemRunner) RunCommand(command string, args []string) error {
 	}
 }
 
+func (mr *MemRunner) RunCommandWithEnv(command string, args []string, extraEnv []string) error {
+	if mr.HistoryEnv == nil {
+		mr.HistoryEnv = make(map[string][]string)
+	}
+	mr.HistoryEnv[strings.Join(append([]string{command}, args...), " ")] = extraEnv
+	return mr.RunCommand(command, args)
+}
+
 func (mr *MemRunner) ExecCommand(command string, args []string) error {
 	return mr.RunCommand(command, args)
 }
@@ -125,6 +148,25 @@ func (mr *MemRunner) ExecCommandWithEnv(command string, args []string, extraEnv
 	return mr.RunCommand(command, args)
 }
 
+func (mr *MemRunner) CommandOutput(command string, args []string) (string, error) {
+	fullCommand := strings.Join(append([]string{command}, args...), " ")
+
+	if e, ok := mr.FailCmds[fullCommand]; ok {
+		return "", e
+	}
+
+	return mr.Outputs[fullCommand], nil
+}
+
+func (mr *MemRunner) CommandOutputWithInput(command string, args []string, input string) (string, error) {
+	if mr.Inputs == nil {
+		mr.Inputs = make(map[string]string)
+	}
+	mr.Inputs[strings.Join(append([]string{command}, args...), " ")] = input
+
+	return mr.CommandOutput(command, args)
+}
+
 func (mr *MemRunner) CommandOutputToFile(command string, args []string, outputFile string) error {
 	if mr.CommandOutputCmds == nil {
 		mr.CommandOutputCmds = make(map[string]string)
@@ -132,6 +174,7 @@ func (mr *MemRunner) CommandOutputToFile(command string, args []string, outputFi
 
 	fullCommand := strings.Join(append([]string{command}, args...), " ")
 	mr.CommandOutputCmds[fullCommand] = outputFile
+	os.Create(outputFile)
 
 	e, ok := mr.FailCmds[fullCommand]
 
@@ -142,6 +185,14 @@ func (mr *MemRunner) CommandOutputToFile(command string, args []string, outputFi
 	}
 }
 
+func (mr *MemRunner) RunCommandInDir(dir, command string, args []string, logFile string) error {
+	if mr.HistoryDir == nil {
+		mr.HistoryDir = make(map[string]string)
+	}
+	mr.HistoryDir[strings.Join(append([]string{command}, args...), " ")] = dir
+	return mr.RunCommand(command, args)
+}
+
 func (mr *MemRunner) FailCheck(fullCommand string) {
 	if mr.FailCheckCmds == nil {
 		mr.FailCheckCmds = make(map[string]bool)
@@ -150,6 +201,24 @@ func (mr *MemRunner) FailCheck(fullCommand string) {
 	mr.FailCheckCmds[fullCommand] = true
 }
 
+func (mr *MemRunner) SetOutput(fullCommand, output string) {
+	if mr.Outputs == nil {
+		mr.Outputs = make(map[string]string)
+	}
+
+	mr.Outputs[fullCommand] = output
+}
+
+// SetEffect registers a function that's called when fullCommand is run,
+// standing in for whatever the real command would have done.
+func (mr *MemRunner) SetEffect(fullCommand string, effect func()) {
+	if mr.Effects == nil {
+		mr.Effects = make(map[string]func())
+	}
+
+	mr.Effects[fullCommand] = effect
+}
+
 func (mr *MemRunner) FailCommand(fullCommand string, err error) {
 	if mr.FailCmds == nil {
 		mr.FailCmds = make(map[string]error)
@@ -174,7 +243,7 @@ func (md *MemDownloader) DownloadFile(url, path string) error {
 	return nil
 }
 
-func (md *MemDownloader) PullDockerImage(image string) error {
+func (md *MemDownloader) PullDockerImage(containerRuntime, image string) error {
 	md.DockerImages = append(md.DockerImages, image)
 
 	return nil
@@ -190,6 +259,10 @@ type MemSystem struct {
 	StdoutMessages []string
 	ArchiveFiles   map[string][]string
 	Env            map[string]string
+	Prompts        []string
+	ConfirmAnswer  bool
+	ConfirmError   error
+	UnpackOptions  map[string]ArchiveOptions
 }
 
 func NewMemSystem() *MemSystem {
@@ -203,6 +276,10 @@ func NewMemSystem() *MemSystem {
 		[]string{},
 		make(map[string][]string),
 		map[string]string{"HOME": os.Getenv("HOME")},
+		[]string{},
+		false,
+		nil,
+		nil,
 	}
 }
 
@@ -239,6 +316,11 @@ func (ms *MemSystem) Stdoutf(message string, args ...interface{}) {
 	ms.StdoutMessages = append(ms.StdoutMessages, fmt.Sprintf(message, args...))
 }
 
+func (ms *MemSystem) Confirm(message string) (bool, error) {
+	ms.Prompts = append(ms.Prompts, message)
+	return ms.ConfirmAnswer, ms.ConfirmError
+}
+
 func (ms *MemSystem) UnpackArchive(archive, destPath string) error {
 	os.MkdirAll(destPath, 0755)
 
@@ -253,6 +335,15 @@ func (ms *MemSystem) UnpackArchive(archive, destPath string) error {
 	return nil
 }
 
+func (ms *MemSystem) UnpackArchiveWithOptions(archive, destPath string, options ArchiveOptions) error {
+	if ms.UnpackOptions == nil {
+		ms.UnpackOptions = make(map[string]ArchiveOptions)
+	}
+	ms.UnpackOptions[path.Base(archive)] = options
+
+	return ms.UnpackArchive(archive, destPath)
+}
+
 func (ms *MemSystem) Getenv(key string) string {
 	val, ok := ms.Env[key]
 	if ok {
@@ -3,10 +3,12 @@ package main
 import (
 	"errors"
 	"fmt"
+	"sort"
 )
 
 // ConfigCommand specifies options for the config subcommand.
 type ConfigCommand struct {
+	OutputOptions
 	System bool `short:"s" long:"system" description:"Modify system level configuration."`
 	Unset  bool `short:"u" long:"unset" description:"Unset key."`
 	List   bool `short:"l" long:"list" description:"List current config values."`
@@ -25,15 +27,15 @@ func (x *ConfigCommand) Execute(args []string) error {
 		return err
 	}
 
-	if configCommand.List {
-		all, err := conf.GetAll()
-		if err != nil {
-			return err
-		}
+	format, err := configCommand.Format()
+	if err != nil {
+		return err
+	}
 
-		for k, v := range all {
-			fmt.Printf("%s = %s\n", k, v)
-		}
+	if configCommand.List {
+		return listConfig(conf, &DefaultSystem{}, format)
+	} else if format != textOutput {
+		return errors.New("--json and --yaml can only be given with --list")
 	} else if configCommand.Unset {
 		err := conf.Unset(configCommand.System, configCommand.Args.Key)
 		if err != nil {
@@ -56,6 +58,31 @@ func (x *ConfigCommand) Execute(args []string) error {
 	return nil
 }
 
+// listConfig shows every configuration value.  As JSON or YAML, that's an
+// object of keys to values.
+func listConfig(conf ConfigClient, system System, format string) error {
+	all, err := conf.GetAll()
+	if err != nil {
+		return err
+	}
+
+	if format != textOutput {
+		return writeStructured(system, format, all)
+	}
+
+	keys := []string{}
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		system.Stdoutf("%s = %s\n", k, all[k])
+	}
+
+	return nil
+}
+
 func init() {
 	_, err := parser.AddCommand("config",
 		"Set and get configuration.",
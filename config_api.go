@@ -18,6 +18,7 @@ type ConfigClient interface {
 
 type ConfigGetter interface {
 	Get(key string) (string, error)
+	GetSystem(key string) (string, error)
 }
 
 type RealConfigClient struct {
@@ -95,6 +96,20 @@ func (rcc *RealConfigClient) Get(key string) (string, error) {
 	return ck.Value(), nil
 }
 
+// GetSystem returns the value of key from the system level configuration
+// only, ignoring any user level override.
+func (rcc *RealConfigClient) GetSystem(key string) (string, error) {
+	cfg, err := ini.LooseLoad(rcc.systemConfig)
+	if err != nil {
+		return "", fmt.Errorf("failure to load config: %v", err)
+	}
+
+	section, k := splitKey(key)
+	ck := cfg.Section(section).Key(k)
+
+	return ck.Value(), nil
+}
+
 func (rcc *RealConfigClient) GetAll() (map[string]string, error) {
 	all := make(map[string]string)
 
@@ -75,3 +75,33 @@ func TestSetGetUnset(t *testing.T) {
 	val, err = conf.Get("section.key")
 	assert.Equal(val, "")
 }
+
+func TestListConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	conf := NewMemConfig()
+	conf.Set(true, "source.extra", "someone/manifests")
+	conf.Set(false, "hold.jq", "true")
+	conf.Set(false, "binary.checksum", "sha256")
+
+	system := NewMemSystem()
+	assert.Nil(listConfig(conf, system, textOutput))
+	assert.Equal([]string{
+		"binary.checksum = sha256\n",
+		"hold.jq = true\n",
+		"source.extra = someone/manifests\n",
+	}, system.StdoutMessages)
+
+	system = NewMemSystem()
+	assert.Nil(listConfig(conf, system, jsonOutput))
+	assert.Equal([]string{`{
+  "binary.checksum": "sha256",
+  "hold.jq": "true",
+  "source.extra": "someone/manifests"
+}
+`}, system.StdoutMessages)
+
+	system = NewMemSystem()
+	assert.Nil(listConfig(NewMemConfig(), system, jsonOutput))
+	assert.Equal([]string{"{}\n"}, system.StdoutMessages)
+}
@@ -0,0 +1,41 @@
+package main
+
+import "fmt"
+
+// GCCommand specifies options for the gc subcommand.
+type GCCommand struct {
+	DryRun bool `short:"n" long:"dry-run" description:"Show what would be removed without removing it"`
+}
+
+var gcCommand GCCommand
+
+// Removing unused versions
+func (x *GCCommand) Execute(args []string) error {
+	manifestFinder, err := NewManifestFinder(true)
+	if err != nil {
+		return err
+	}
+
+	referenced, err := manifestFinder.ReferencedVersions()
+	if err != nil {
+		return err
+	}
+
+	inventory, err := NewInventory()
+	if err != nil {
+		return err
+	}
+
+	return inventory.GarbageCollect(referenced, gcCommand.DryRun)
+}
+
+func init() {
+	_, err := parser.AddCommand("gc",
+		"Remove installed versions that are no longer used.",
+		"",
+		&gcCommand)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -6,26 +6,22 @@ require (
 	github.com/Sirupsen/logrus v0.10.1-0.20160829202321-3ec0642a7fb6
 	github.com/hashicorp/go-version v1.0.0
 	github.com/jessevdk/go-flags v0.0.0-20160903113131-4cc2832a6e6d
-	github.com/justone/archiver v2.0.1-0.20180919230831-da9f0232829f+incompatible
 	github.com/kardianos/osext v0.0.0-20160811001526-c2c54e542fb7
+	github.com/klauspost/compress v1.17.0
 	github.com/kr/pretty v0.2.1
 	github.com/mitchellh/go-homedir v0.0.0-20161203194507-b8bc1bf76747
 	github.com/pkg/errors v0.7.1
 	github.com/stretchr/testify v1.4.0
+	github.com/ulikunitz/xz v0.5.12
 	gopkg.in/ini.v1 v1.21.1
 	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/dsnet/compress v0.0.0-20160704023633-b9aab3c6a04e // indirect
-	github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db // indirect
 	github.com/kr/text v0.1.0 // indirect
-	github.com/nwaples/rardecode v0.0.0-20160723083021-f94841372ddc // indirect
-	github.com/pierrec/lz4 v2.0.5+incompatible // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/smartystreets/goconvey v1.8.1 // indirect
-	github.com/ulikunitz/xz v0.5.5-0.20180703112113-636d36a76670 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 )
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// VersionInfo is a version of a utility and the strategies that give it.
+type VersionInfo struct {
+	Version    string
+	Strategies []string
+}
+
+// UtilityInfo brings together what the manifests say about a utility and
+// what's installed of it.
+type UtilityInfo struct {
+	Name     string
+	Desc     string
+	Homepage string
+	License  string
+	Source   string
+
+	// Shadowed lists the other sources with a manifest for the utility,
+	// which aren't used since Source comes first.
+	Shadowed   []string
+	Commands   []string
+	Strategies []string
+	Versions   []VersionInfo
+	Installed  []*InstalledVersion
+}
+
+// Info finds the manifest for a utility the same way running it would, and
+// adds the versions of it in installed.
+func (dmf DefaultManifestFinder) Info(name string, installed []*InstalledVersion) (*UtilityInfo, error) {
+	sourcePaths, err := dmf.Paths("")
+	if err != nil {
+		return nil, err
+	}
+
+	var manifestPath string
+	for _, p := range sourcePaths {
+		tryPath := filepath.Join(p, fmt.Sprintf("%s.yaml", name))
+		if _, err := os.Stat(tryPath); err == nil {
+			manifestPath = tryPath
+			break
+		}
+	}
+
+	if len(manifestPath) == 0 {
+		for _, p := range sourcePaths {
+			manifestPath = dmf.findProvider(p, name)
+			if len(manifestPath) > 0 {
+				name = manifestName(manifestPath)
+				break
+			}
+		}
+	}
+
+	if len(manifestPath) == 0 {
+		return nil, fmt.Errorf("unable to find manifest for %s", name)
+	}
+
+	md, err := readManifestData(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	md.Name = name
+
+	info := &UtilityInfo{
+		Name:     name,
+		Desc:     md.Desc,
+		Homepage: md.Homepage,
+		License:  md.License,
+		Commands: md.Commands(),
+	}
+
+	for _, p := range sourcePaths {
+		if _, err := os.Stat(filepath.Join(p, fmt.Sprintf("%s.yaml", name))); err != nil {
+			continue
+		}
+		if len(info.Source) == 0 {
+			info.Source = sourceName(p)
+		} else {
+			info.Shadowed = append(info.Shadowed, sourceName(p))
+		}
+	}
+	if len(info.Source) == 0 {
+		info.Source = sourceName(filepath.Dir(manifestPath))
+	}
+
+	for strategy := range md.Strategies {
+		info.Strategies = append(info.Strategies, strategy)
+	}
+	sort.Strings(info.Strategies)
+
+	versionStrategies := md.VersionStrategies()
+	for version, strategies := range versionStrategies {
+		info.Versions = append(info.Versions, VersionInfo{version, strategies})
+	}
+	sort.Slice(info.Versions, func(i, j int) bool {
+		return compareVersions(info.Versions[i].Version, info.Versions[j].Version) > 0
+	})
+
+	for _, iv := range installed {
+		if iv.Name == name {
+			refineStrategies(iv, versionStrategies[iv.Version])
+			info.Installed = append(info.Installed, iv)
+		}
+	}
+
+	return info, nil
+}
+
+// ShowInfo shows what's known about a utility.
+func (dmf DefaultManifestFinder) ShowInfo(name string, installed []*InstalledVersion) error {
+	info, err := dmf.Info(name, installed)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "%s: %s\n", info.Name, info.Desc)
+	if len(info.Homepage) > 0 {
+		fmt.Fprintf(&out, " homepage: %s\n", info.Homepage)
+	}
+	if len(info.License) > 0 {
+		fmt.Fprintf(&out, " license: %s\n", info.License)
+	}
+	fmt.Fprintf(&out, " source: %s\n", info.Source)
+	if len(info.Shadowed) > 0 {
+		fmt.Fprintf(&out, " shadowed: %s\n", strings.Join(info.Shadowed, ", "))
+	}
+	if len(info.Commands) > 0 {
+		fmt.Fprintf(&out, " commands: %s\n", strings.Join(info.Commands, ", "))
+	}
+	fmt.Fprintf(&out, " strategies: %s\n", strings.Join(info.Strategies, ", "))
+
+	fmt.Fprintf(&out, " versions:\n")
+	writer := tabwriter.NewWriter(&out, 0, 8, 2, ' ', 0)
+	for _, version := range info.Versions {
+		fmt.Fprintf(writer, "  %s\t%s\n", version.Version, strings.Join(version.Strategies, ","))
+	}
+	writer.Flush()
+
+	if len(info.Installed) == 0 {
+		fmt.Fprintf(&out, " installed: none\n")
+	} else {
+		showTime := func(t time.Time, missing string) string {
+			if t.IsZero() {
+				return missing
+			}
+			return t.Local().Format("2006-01-02 15:04")
+		}
+
+		fmt.Fprintf(&out, " installed:\n")
+		writer = tabwriter.NewWriter(&out, 0, 8, 2, ' ', 0)
+		for _, iv := range info.Installed {
+			fmt.Fprintf(writer, "  %s\t%s\t%s\tinstalled %s\tlast run %s\n",
+				iv.Version, strings.Join(iv.Strategies, ","), formatSize(iv.Size),
+				showTime(iv.Installed, "-"), showTime(iv.LastUsed, "never"))
+		}
+		writer.Flush()
+	}
+
+	dmf.Stdoutf("%s", out.String())
+	return nil
+}
+
+// InfoCommand specifies options for the info subcommand.
+type InfoCommand struct {
+	Args struct {
+		Name string `description:"Name of utility."`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var infoCommand InfoCommand
+
+// Showing a summary of a utility
+func (x *InfoCommand) Execute(args []string) error {
+	manifestFinder, err := NewManifestFinder(true)
+	if err != nil {
+		return err
+	}
+
+	inventory, err := NewInventory()
+	if err != nil {
+		return err
+	}
+
+	installed, err := inventory.Find()
+	if err != nil {
+		return err
+	}
+
+	return manifestFinder.ShowInfo(infoCommand.Args.Name, installed)
+}
+
+func init() {
+	_, err := parser.AddCommand("info",
+		"Summarize a utility across sources and strategies.",
+		"",
+		&infoCommand)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+}
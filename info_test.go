@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+	tu, manifestFinder := newTestManifestFinder("")
+	tu.MemSourcePather.TestPaths = []string{
+		path.Join(wd, "testdata", "search", "main", "manifests"),
+		path.Join(wd, "testdata", "search", "extra"),
+		path.Join(wd, "testdata", "provides", "manifests"),
+	}
+
+	installed := []*InstalledVersion{
+		{Name: "jq", Version: "1.5", Strategies: []string{"binary"}},
+		{Name: "yq", Version: "4.35.1", Strategies: []string{"binary"}},
+	}
+
+	info, err := manifestFinder.Info("jq", installed)
+	assert.Nil(err)
+	assert.Equal("jq", info.Name)
+	assert.Equal("https://jqlang.github.io/jq/", info.Homepage)
+	assert.Equal("MIT", info.License)
+	assert.Equal("main", info.Source)
+	assert.Equal([]string{"extra"}, info.Shadowed)
+	assert.Equal([]string{"binary", "docker"}, info.Strategies)
+	assert.Equal([]VersionInfo{
+		{"1.6", []string{"binary", "docker"}},
+		{"1.5", []string{"binary"}},
+	}, info.Versions)
+	assert.Equal(installed[:1], info.Installed)
+
+	info, err = manifestFinder.Info("yq", installed)
+	assert.Nil(err)
+	assert.Equal("extra", info.Source)
+	assert.Empty(info.Shadowed)
+	assert.Equal(installed[1:], info.Installed)
+
+	info, err = manifestFinder.Info("protoc-gen-doc", installed)
+	assert.Nil(err)
+	assert.Equal("protobuf", info.Name)
+	assert.Equal("provides", info.Source)
+	assert.Equal([]string{"protoc", "protoc-gen-doc", "protoc-gen-grpc"}, info.Commands)
+
+	_, err = manifestFinder.Info("missing", installed)
+	assert.EqualError(err, "unable to find manifest for missing")
+}
+
+func TestShowInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+	installedAt := time.Date(2026, 3, 4, 5, 6, 0, 0, time.Local)
+
+	var tests = []struct {
+		name      string
+		installed []*InstalledVersion
+		result    string
+	}{
+		{
+			"jq",
+			[]*InstalledVersion{
+				{Name: "jq", Version: "1.5", Strategies: []string{"binary"}, Size: 2048, Installed: installedAt},
+			},
+			"jq: Lightweight and flexible command-line JSON processor\n" +
+				" homepage: https://jqlang.github.io/jq/\n" +
+				" license: MIT\n" +
+				" source: main\n" +
+				" shadowed: extra\n" +
+				" strategies: binary, docker\n" +
+				" versions:\n" +
+				"  1.6  binary,docker\n" +
+				"  1.5  binary\n" +
+				" installed:\n" +
+				"  1.5  binary  2.0K  installed 2026-03-04 05:06  last run never\n",
+		},
+		{
+			"terraform",
+			nil,
+			"terraform: Infrastructure as code\n" +
+				" source: main\n" +
+				" strategies: binary\n" +
+				" versions:\n" +
+				"  1.5.7  binary\n" +
+				"  1.5.6  binary\n" +
+				"  1.4.0  binary\n" +
+				" installed: none\n",
+		},
+	}
+
+	for _, test := range tests {
+		tu, manifestFinder := newTestManifestFinder("")
+		tu.MemSourcePather.TestPaths = []string{
+			path.Join(wd, "testdata", "search", "main", "manifests"),
+			path.Join(wd, "testdata", "search", "extra"),
+		}
+
+		err := manifestFinder.ShowInfo(test.name, test.installed)
+		assert.Nil(err)
+		assert.Equal([]string{test.result}, tu.MemSystem.StdoutMessages)
+	}
+}
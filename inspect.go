@@ -1,9 +1,135 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
+
+// Inspection holds what a strategy shows when it's inspected, so that it
+// can be shown as text or as structured output.
+type Inspection struct {
+	Strategy string
+	Title    string
+	Version  string
+	fields   []inspectField
+}
+
+type inspectField struct {
+	key   string
+	value interface{}
+	lines []string
+}
+
+// InspectReport is how an inspected strategy is shown as JSON or YAML.
+type InspectReport struct {
+	Strategy string                 `json:"strategy" yaml:"strategy"`
+	Version  string                 `json:"version" yaml:"version"`
+	Details  map[string]interface{} `json:"details" yaml:"details"`
+}
+
+func newInspection(strategy, title, version string) *Inspection {
+	return &Inspection{Strategy: strategy, Title: title, Version: version}
+}
+
+// add sets a detail, along with the lines of text that show it.
+func (in *Inspection) add(key string, value interface{}, lines ...string) {
+	in.fields = append(in.fields, inspectField{key, value, lines})
+}
+
+// Add sets a detail that's shown as "label: value".
+func (in *Inspection) Add(key, label string, value interface{}) {
+	in.add(key, value, fmt.Sprintf("  %s: %v\n", label, value))
+}
+
+// AddEach sets a detail with several values, each shown as "label: value".
+func (in *Inspection) AddEach(key, label string, values []string) {
+	if values == nil {
+		values = []string{}
+	}
+
+	lines := []string{}
+	for _, value := range values {
+		lines = append(lines, fmt.Sprintf("  %s: %s\n", label, value))
+	}
+	in.add(key, values, lines...)
+}
+
+// AddList sets a detail with several values, shown below the label.
+func (in *Inspection) AddList(key, label string, values []string) {
+	if values == nil {
+		values = []string{}
+	}
+
+	lines := []string{fmt.Sprintf("  %s:\n", label)}
+	for _, value := range values {
+		lines = append(lines, fmt.Sprintf("    %s\n", value))
+	}
+	in.add(key, values, lines...)
+}
+
+// AddMap sets a detail with named values, shown below the label in order
+// of name.
+func (in *Inspection) AddMap(key, label string, values map[string]string) {
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{fmt.Sprintf("  %s:\n", label)}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("    %s: %s\n", name, values[name]))
+	}
+	in.add(key, values, lines...)
+}
+
+// AddChecksum sets the checksum that a download is verified with, if there
+// is one.
+func (in *Inspection) AddChecksum(algo, sum string) {
+	if len(algo) == 0 {
+		return
+	}
+
+	in.add("checksum", map[string]string{"algorithm": algo, "sum": sum},
+		fmt.Sprintf("  checksum with %s: %s\n", algo, sum))
+}
+
+// Lines returns the inspection as the lines of text that inspect shows.
+func (in *Inspection) Lines() []string {
+	lines := []string{fmt.Sprintf("%s (version: %s):\n", in.Title, in.Version)}
+	for _, field := range in.fields {
+		lines = append(lines, field.lines...)
+	}
+
+	return lines
+}
+
+// Report returns the inspection as it's shown as JSON or YAML.
+func (in *Inspection) Report() InspectReport {
+	details := make(map[string]interface{})
+	for _, field := range in.fields {
+		details[field.key] = field.value
+	}
+
+	return InspectReport{in.Strategy, in.Version, details}
+}
+
+// showInspection prints an inspection as text.
+func (sc *StrategyCommon) showInspection(inspection *Inspection, err error) error {
+	if err != nil {
+		return err
+	}
+
+	for _, line := range inspection.Lines() {
+		sc.Stdoutf("%s", line)
+	}
+
+	return nil
+}
 
 // InspectCommand specifies options for the inspect subcommand.
 type InspectCommand struct {
+	OutputOptions
 	Manifest string `short:"m" long:"manifest" description:"Manifest file, specify to override search."`
 	Version  string `short:"v" long:"version" description:"Version of the utility to inspect. (optional)"`
 	Args     struct {
@@ -27,6 +153,11 @@ func (x *InspectCommand) Execute(args []string) error {
 func runInspect(inspectCommand InspectCommand, conf ConfigGetter, logger Logger, system System) error {
 	var err error
 
+	format, err := inspectCommand.Format()
+	if err != nil {
+		return err
+	}
+
 	utility := NameVer{inspectCommand.Args.Name, inspectCommand.Version}
 
 	var manifest *Manifest
@@ -47,32 +178,36 @@ func runInspect(inspectCommand InspectCommand, conf ConfigGetter, logger Logger,
 		}
 	}
 
+	var strategies []Strategy
 	if len(utility.Version) == 0 {
-		allStrategies, err := manifest.LoadAllStrategies(utility)
-		if err != nil {
-			return err
-		}
+		strategies, err = manifest.LoadAllStrategies(utility)
+	} else {
+		strategies, err = manifest.LoadStrategies(utility)
+	}
+	if err != nil {
+		return err
+	}
 
-		for _, strategy := range allStrategies {
+	if format == textOutput {
+		for _, strategy := range strategies {
 			err = strategy.Inspect()
 			if err != nil {
 				return err
 			}
 		}
-	} else {
-		strategies, err := manifest.LoadStrategies(utility)
+		return nil
+	}
+
+	reports := []InspectReport{}
+	for _, strategy := range strategies {
+		inspection, err := strategy.Inspection()
 		if err != nil {
 			return err
 		}
-
-		for _, strategy := range strategies {
-			err = strategy.Inspect()
-			if err != nil {
-				return err
-			}
-		}
+		reports = append(reports, inspection.Report())
 	}
-	return nil
+
+	return writeStructured(system, format, reports)
 }
 
 func init() {
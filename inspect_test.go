@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspection(t *testing.T) {
+	assert := assert.New(t)
+
+	inspection := newInspection("binary", "Binary Strategy", "1.5")
+	inspection.Add("final_url", "final url", "https://example.com/jq")
+	inspection.Add("strip_components", "strip components", 1)
+	inspection.AddEach("env", "env", []string{"A=1", "B=2"})
+	inspection.AddList("build_steps", "build steps", nil)
+	inspection.AddMap("provides", "provides", map[string]string{"jq": "bin/jq", "bq": "bin/bq"})
+	inspection.AddChecksum("", "")
+	inspection.AddChecksum("sha256", "abcd")
+
+	assert.Equal([]string{
+		"Binary Strategy (version: 1.5):\n",
+		"  final url: https://example.com/jq\n",
+		"  strip components: 1\n",
+		"  env: A=1\n",
+		"  env: B=2\n",
+		"  build steps:\n",
+		"  provides:\n",
+		"    bq: bin/bq\n",
+		"    jq: bin/jq\n",
+		"  checksum with sha256: abcd\n",
+	}, inspection.Lines())
+
+	assert.Equal(InspectReport{
+		Strategy: "binary",
+		Version:  "1.5",
+		Details: map[string]interface{}{
+			"final_url":        "https://example.com/jq",
+			"strip_components": 1,
+			"env":              []string{"A=1", "B=2"},
+			"build_steps":      []string{},
+			"provides":         map[string]string{"jq": "bin/jq", "bq": "bin/bq"},
+			"checksum":         map[string]string{"algorithm": "sha256", "sum": "abcd"},
+		},
+	}, inspection.Report())
+}
+
+func TestRunInspectStructured(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+	command := InspectCommand{Manifest: path.Join(wd, "testdata", "single", "manifests", "jq.yaml")}
+	command.Args.Name = "jq"
+	command.Version = "1.5"
+	command.JSON = true
+
+	system := NewMemSystem()
+	system.MOS = "linux"
+	system.MArch = "amd64"
+	assert.Nil(runInspect(command, &MemConfig{}, &MemLogger{}, system))
+
+	var reports []map[string]interface{}
+	assert.Nil(json.Unmarshal([]byte(system.StdoutMessages[0]), &reports))
+	assert.Len(reports, 3)
+
+	strategies := []string{}
+	for _, report := range reports {
+		assert.Equal("1.5", report["version"])
+		strategies = append(strategies, report["strategy"].(string))
+	}
+	assert.ElementsMatch([]string{"binary", "docker", "cmdio"}, strategies)
+
+	for _, report := range reports {
+		details := report["details"].(map[string]interface{})
+		switch report["strategy"] {
+		case "binary":
+			assert.Equal("https://github.com/stedolan/jq/releases/download/jq-1.5/jq-linux64", details["final_url"])
+		case "docker":
+			assert.Equal("jemmyw/jq:1.5", details["final_image"])
+			assert.IsType([]interface{}{}, details["final_command"])
+		case "cmdio":
+			assert.Equal("justone/jq--1.5", details["final_command"])
+		}
+	}
+
+	command.JSON = false
+	command.YAML = true
+	system = NewMemSystem()
+	assert.Nil(runInspect(command, &MemConfig{}, &MemLogger{}, system))
+	assert.Contains(system.StdoutMessages[0], "- strategy: ")
+	assert.Contains(system.StdoutMessages[0], "  details:\n")
+
+	command.JSON = true
+	assert.EqualError(runInspect(command, &MemConfig{}, &MemLogger{}, system), "only one of --json and --yaml can be given")
+}
@@ -0,0 +1,586 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// InstalledVersion is a version of a utility that holen has put on disk, or
+// run as a docker image.
+type InstalledVersion struct {
+	Name       string
+	Version    string
+	Strategies []string
+	Paths      []string
+	Runtime    string
+	Image      string
+	Size       int64
+	Installed  time.Time
+	LastUsed   time.Time
+}
+
+// Key returns the name and version in the form used for file names.
+func (iv InstalledVersion) Key() string {
+	return fmt.Sprintf("%s--%s", iv.Name, iv.Version)
+}
+
+// LastActive returns when the version was last run, or installed if it
+// never has been.
+func (iv InstalledVersion) LastActive() time.Time {
+	if iv.LastUsed.After(iv.Installed) {
+		return iv.LastUsed
+	}
+	return iv.Installed
+}
+
+// Inventory finds what holen has installed and removes it again.
+type Inventory struct {
+	Logger
+	ConfigGetter
+	System
+	Runner
+}
+
+// installLayout lists the directories under the data path that strategies
+// install into, and whether each version is a <name>--<version> entry or a
+// <name>/<version> directory.
+var installLayout = []struct {
+	dir      string
+	strategy string
+	nested   bool
+}{
+	{"pkgs", "binary", true},
+	{"appimage", "appimage", false},
+	{"scripts", "script", false},
+	{"go", "go", true},
+	{"python", "python", true},
+	{"npm", "npm", true},
+	{"build", "source", false},
+}
+
+// scriptExtension matches the extension kept on cached scripts, which unlike
+// a version always starts with a letter.
+var scriptExtension = regexp.MustCompile(`\.[A-Za-z][A-Za-z0-9]*$`)
+
+// splitInstalled splits a <name>--<version> file name, dropping any
+// extension that was added after the version.
+func splitInstalled(fileName string) (string, string, string) {
+	nameVer := ParseName(fileName)
+	if len(nameVer.Name) == 0 || len(nameVer.Version) == 0 {
+		return "", "", ""
+	}
+
+	version := nameVer.Version
+	strategy := ""
+	for _, suffix := range []string{".AppImage", ".exe"} {
+		if strings.HasSuffix(version, suffix) {
+			version = strings.TrimSuffix(version, suffix)
+			if suffix == ".AppImage" {
+				strategy = "appimage"
+			}
+		}
+	}
+
+	return nameVer.Name, version, strategy
+}
+
+// Find returns every installed version, sorted by name and version.
+func (inv Inventory) Find() ([]*InstalledVersion, error) {
+	found := make(map[string]*InstalledVersion)
+	add := func(name, version, strategy, path string) {
+		key := fmt.Sprintf("%s--%s", name, version)
+		iv, ok := found[key]
+		if !ok {
+			iv = &InstalledVersion{Name: name, Version: version}
+			found[key] = iv
+		}
+
+		if len(path) > 0 {
+			iv.Paths = append(iv.Paths, path)
+			iv.Size += diskUsage(path)
+			if info, err := os.Lstat(path); err == nil {
+				if iv.Installed.IsZero() || info.ModTime().Before(iv.Installed) {
+					iv.Installed = info.ModTime()
+				}
+			}
+		}
+		if !containsString(iv.Strategies, strategy) {
+			iv.Strategies = append(iv.Strategies, strategy)
+		}
+	}
+
+	holenPath, err := inv.DataPath()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get holen data path")
+	}
+
+	common := &StrategyCommon{System: inv.System, Logger: inv.Logger, ConfigGetter: inv.ConfigGetter}
+	downloadPath, err := common.DownloadPath()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find download path")
+	}
+
+	// a configured download path may be shared, so only what holen
+	// recorded putting there counts
+	sharedDownloads := filepath.Clean(downloadPath) != filepath.Join(holenPath, "bin")
+	entries, _ := ioutil.ReadDir(downloadPath)
+	for _, entry := range entries {
+		name, version, strategy := splitInstalled(entry.Name())
+		if len(name) == 0 {
+			continue
+		}
+		if sharedDownloads && !downloaded(inv.System, entry.Name()) {
+			inv.Debugf("skipping %s, it wasn't downloaded by holen", entry.Name())
+			continue
+		}
+		if len(strategy) == 0 {
+			// binaries, oci and source builds all end up here
+			strategy = "binary"
+		}
+		add(name, version, strategy, filepath.Join(downloadPath, entry.Name()))
+	}
+
+	for _, layout := range installLayout {
+		layoutPath := filepath.Join(holenPath, layout.dir)
+		entries, _ := ioutil.ReadDir(layoutPath)
+		for _, entry := range entries {
+			if !layout.nested {
+				fileName := entry.Name()
+				if layout.dir == "scripts" {
+					fileName = scriptExtension.ReplaceAllString(fileName, "")
+				}
+				name, version, _ := splitInstalled(fileName)
+				if len(name) > 0 {
+					add(name, version, layout.strategy, filepath.Join(layoutPath, entry.Name()))
+				}
+				continue
+			}
+
+			if !entry.IsDir() {
+				continue
+			}
+			versions, _ := ioutil.ReadDir(filepath.Join(layoutPath, entry.Name()))
+			for _, version := range versions {
+				add(entry.Name(), version.Name(), layout.strategy, filepath.Join(layoutPath, entry.Name(), version.Name()))
+			}
+		}
+	}
+
+	// docker images are only known from when they were run
+	records, _ := ioutil.ReadDir(filepath.Join(holenPath, "usage"))
+	for _, record := range records {
+		name, version, _ := splitInstalled(record.Name())
+		if len(name) == 0 {
+			continue
+		}
+		if usage, _ := readUsage(inv.System, name, version); len(usage.Image) > 0 {
+			add(name, version, "docker", "")
+			found[fmt.Sprintf("%s--%s", name, version)].Runtime = usage.Runtime
+			found[fmt.Sprintf("%s--%s", name, version)].Image = usage.Image
+		}
+	}
+
+	installed := []*InstalledVersion{}
+	for _, iv := range found {
+		_, iv.LastUsed = readUsage(inv.System, iv.Name, iv.Version)
+		installed = append(installed, iv)
+	}
+	sort.Slice(installed, func(i, j int) bool {
+		if installed[i].Name != installed[j].Name {
+			return installed[i].Name < installed[j].Name
+		}
+		return installed[i].Version < installed[j].Version
+	})
+
+	return installed, nil
+}
+
+// Remove deletes everything installed for a version, along with its usage
+// record.  Docker images are removed unless one of the versions in keep
+// still uses them.
+func (inv Inventory) Remove(iv *InstalledVersion, keep []*InstalledVersion) error {
+	for _, path := range iv.Paths {
+		inv.Debugf("removing %s", path)
+		err := os.RemoveAll(path)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("unable to remove %s", path))
+		}
+
+		// don't leave behind empty <name> directories
+		if inv.nested(path) {
+			os.Remove(filepath.Dir(path))
+		}
+
+		if recordPath, err := downloadRecordPath(inv.System, filepath.Base(path)); err == nil {
+			os.Remove(recordPath)
+		}
+	}
+
+	if len(iv.Image) > 0 && !imageInUse(iv, keep) {
+		inv.Debugf("removing image %s", iv.Image)
+		err := inv.RunCommand(iv.Runtime, []string{"rmi", iv.Image})
+		if err != nil {
+			inv.Warnf("unable to remove image %s: %s", iv.Image, err)
+		}
+	}
+
+	recordPath, err := usagePath(inv.System, iv.Name, iv.Version)
+	if err == nil {
+		os.Remove(recordPath)
+	}
+
+	return nil
+}
+
+// nested reports whether path is a <name>/<version> directory in one of the
+// nested install layouts.
+func (inv Inventory) nested(path string) bool {
+	holenPath, err := inv.DataPath()
+	if err != nil {
+		return false
+	}
+
+	for _, layout := range installLayout {
+		if layout.nested && filepath.Dir(filepath.Dir(path)) == filepath.Join(holenPath, layout.dir) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// imageInUse reports whether any of the other versions run the same image.
+func imageInUse(iv *InstalledVersion, others []*InstalledVersion) bool {
+	for _, other := range others {
+		if other != iv && other.Image == iv.Image && other.Runtime == iv.Runtime {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diskUsage returns the size of path and everything under it, without
+// following symlinks.
+func diskUsage(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size
+}
+
+// removeInstalled removes each version, printing what it's doing, and
+// reports how much space was freed.  With dryRun, nothing is removed.
+func (inv Inventory) removeInstalled(remove, all []*InstalledVersion, dryRun bool) error {
+	keep := []*InstalledVersion{}
+	for _, iv := range all {
+		removing := false
+		for _, other := range remove {
+			if iv == other {
+				removing = true
+			}
+		}
+		if !removing {
+			keep = append(keep, iv)
+		}
+	}
+
+	var freed int64
+	for _, iv := range remove {
+		description := fmt.Sprintf("%s %s (%s, %s)", iv.Name, iv.Version, strings.Join(iv.Strategies, ", "), formatSize(iv.Size))
+		if dryRun {
+			inv.Stdoutf("Would remove %s\n", description)
+		} else {
+			err := inv.Remove(iv, keep)
+			if err != nil {
+				return err
+			}
+			inv.Stdoutf("Removed %s\n", description)
+		}
+		freed += iv.Size
+	}
+
+	if dryRun {
+		inv.Stdoutf("Would free %s\n", formatSize(freed))
+	} else {
+		inv.Stdoutf("Freed %s\n", formatSize(freed))
+	}
+
+	return nil
+}
+
+// Uninstall removes the installed versions of a utility, or just the one
+// version if it's given.
+func (inv Inventory) Uninstall(utility NameVer, dryRun bool) error {
+	all, err := inv.Find()
+	if err != nil {
+		return err
+	}
+
+	remove := []*InstalledVersion{}
+	for _, iv := range all {
+		if iv.Name == utility.Name && (len(utility.Version) == 0 || iv.Version == utility.Version) {
+			remove = append(remove, iv)
+		}
+	}
+
+	if len(remove) == 0 {
+		if len(utility.Version) > 0 {
+			return fmt.Errorf("%s %s is not installed", utility.Name, utility.Version)
+		}
+		return fmt.Errorf("%s is not installed", utility.Name)
+	}
+
+	return inv.removeInstalled(remove, all, dryRun)
+}
+
+// GarbageCollect removes installed versions that aren't referenced, by a
+// manifest or a versioned link, and, if gc.max_age is set, those that
+// haven't been run for that long.
+func (inv Inventory) GarbageCollect(referenced map[string]bool, dryRun bool) error {
+	var maxAge time.Duration
+	if configMaxAge, err := inv.Get("gc.max_age"); err == nil && len(configMaxAge) > 0 {
+		parsed, err := time.ParseDuration(configMaxAge)
+		if err != nil {
+			inv.Warnf("invalid gc.max_age %s, only removing unreferenced versions", configMaxAge)
+		} else {
+			maxAge = parsed
+		}
+	}
+
+	all, err := inv.Find()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	remove := []*InstalledVersion{}
+	for _, iv := range all {
+		if !referenced[iv.Key()] {
+			inv.Debugf("%s is no longer referenced", iv.Key())
+			remove = append(remove, iv)
+		} else if maxAge > 0 && iv.LastActive().Before(cutoff) {
+			inv.Debugf("%s was last used %s", iv.Key(), iv.LastActive())
+			remove = append(remove, iv)
+		}
+	}
+
+	return inv.removeInstalled(remove, all, dryRun)
+}
+
+// AddImages looks for the docker images of the given versions, adding the
+// ones that are there to installed, and fills in the size of every image.
+func (inv Inventory) AddImages(installed, images []*InstalledVersion) []*InstalledVersion {
+	byKey := make(map[string]*InstalledVersion)
+	for _, iv := range installed {
+		byKey[iv.Key()] = iv
+	}
+
+	for _, image := range images {
+		iv, ok := byKey[image.Key()]
+		if !ok {
+			iv = &InstalledVersion{Name: image.Name, Version: image.Version}
+		}
+		if len(iv.Image) > 0 {
+			continue
+		}
+
+		iv.Runtime = image.Runtime
+		iv.Image = image.Image
+		if !ok {
+			iv.Strategies = []string{"docker"}
+			installed = append(installed, iv)
+			byKey[iv.Key()] = iv
+		} else if !containsString(iv.Strategies, "docker") {
+			iv.Strategies = append(iv.Strategies, "docker")
+		}
+	}
+
+	found := []*InstalledVersion{}
+	for _, iv := range installed {
+		if len(iv.Image) > 0 {
+			output, err := inv.CommandOutput(iv.Runtime, []string{"image", "inspect", "--format", "{{.Size}}", iv.Image})
+			if err != nil {
+				inv.Debugf("image %s not found: %s", iv.Image, err)
+				if len(iv.Paths) == 0 {
+					continue
+				}
+			} else if size, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64); err == nil {
+				iv.Size += size
+			}
+		}
+		found = append(found, iv)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Key() < found[j].Key()
+	})
+
+	return found
+}
+
+// refineStrategies names the strategy that put a binary in the download
+// path, since binary, oci and source strategies all install there.
+func refineStrategies(iv *InstalledVersion, manifestStrategies []string) {
+	if len(manifestStrategies) == 0 || containsString(manifestStrategies, "binary") {
+		return
+	}
+
+	for i, strategy := range iv.Strategies {
+		if strategy != "binary" {
+			continue
+		}
+		for _, alternative := range []string{"oci", "source"} {
+			if containsString(manifestStrategies, alternative) {
+				iv.Strategies[i] = alternative
+				break
+			}
+		}
+	}
+	iv.Strategies = uniqueStrings(iv.Strategies)
+}
+
+// InstalledReport is how an installed version is shown as JSON or YAML.
+type InstalledReport struct {
+	Name       string     `json:"name" yaml:"name"`
+	Version    string     `json:"version" yaml:"version"`
+	Strategies []string   `json:"strategies" yaml:"strategies"`
+	Size       int64      `json:"size" yaml:"size"`
+	Installed  *time.Time `json:"installed" yaml:"installed"`
+	LastRun    *time.Time `json:"last_run" yaml:"last_run"`
+	InManifest bool       `json:"in_manifest" yaml:"in_manifest"`
+	Paths      []string   `json:"paths" yaml:"paths"`
+	Image      string     `json:"image,omitempty" yaml:"image,omitempty"`
+}
+
+// Report shows the installed versions, noting which of them are still in
+// a manifest.
+func (inv Inventory) Report(installed []*InstalledVersion, manifestVersions map[string][]string, format string) error {
+	reports := []InstalledReport{}
+	for _, iv := range installed {
+		refineStrategies(iv, manifestVersions[iv.Key()])
+
+		report := InstalledReport{
+			Name:       iv.Name,
+			Version:    iv.Version,
+			Strategies: iv.Strategies,
+			Size:       iv.Size,
+			InManifest: len(manifestVersions[iv.Key()]) > 0,
+			Paths:      iv.Paths,
+			Image:      iv.Image,
+		}
+		if report.Paths == nil {
+			report.Paths = []string{}
+		}
+		if !iv.Installed.IsZero() {
+			installedAt := iv.Installed.UTC()
+			report.Installed = &installedAt
+		}
+		if !iv.LastUsed.IsZero() {
+			lastRun := iv.LastUsed.UTC()
+			report.LastRun = &lastRun
+		}
+		reports = append(reports, report)
+	}
+
+	if format != textOutput {
+		return writeStructured(inv.System, format, reports)
+	}
+
+	showTime := func(t *time.Time, missing string) string {
+		if t == nil {
+			return missing
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	}
+
+	var table bytes.Buffer
+	writer := tabwriter.NewWriter(&table, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tVERSION\tSTRATEGY\tSIZE\tINSTALLED\tLAST RUN\tIN MANIFEST")
+	for _, report := range reports {
+		inManifest := "no"
+		if report.InManifest {
+			inManifest = "yes"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			report.Name, report.Version, strings.Join(report.Strategies, ","), formatSize(report.Size),
+			showTime(report.Installed, "-"), showTime(report.LastRun, "never"), inManifest)
+	}
+	writer.Flush()
+
+	inv.Stdoutf("%s", table.String())
+	return nil
+}
+
+// InstalledCommand specifies options for the installed subcommand.
+type InstalledCommand struct {
+	OutputOptions
+	Docker bool `short:"d" long:"docker" description:"Also look for the docker images of versions in manifests"`
+}
+
+var installedCommand InstalledCommand
+
+// Listing installed utilities
+func (x *InstalledCommand) Execute(args []string) error {
+	format, err := installedCommand.Format()
+	if err != nil {
+		return err
+	}
+
+	manifestFinder, err := NewManifestFinder(true)
+	if err != nil {
+		return err
+	}
+
+	manifestVersions, err := manifestFinder.ManifestVersions()
+	if err != nil {
+		return err
+	}
+
+	inventory, err := NewInventory()
+	if err != nil {
+		return err
+	}
+
+	installed, err := inventory.Find()
+	if err != nil {
+		return err
+	}
+
+	if installedCommand.Docker {
+		images, err := manifestFinder.DockerImages()
+		if err != nil {
+			return err
+		}
+		installed = inventory.AddImages(installed, images)
+	}
+
+	return inventory.Report(installed, manifestVersions, format)
+}
+
+func init() {
+	_, err := parser.AddCommand("installed",
+		"List what holen has installed.",
+		"",
+		&installedCommand)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newInventory(tempdir string) (*TestUtils, *Inventory) {
+	tu := &TestUtils{
+		MemSystem: NewMemSystem(),
+		MemLogger: &MemLogger{},
+		MemConfig: NewMemConfig(),
+		MemRunner: &MemRunner{},
+	}
+	tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+
+	return tu, &Inventory{
+		Logger:       tu.MemLogger,
+		ConfigGetter: tu.MemConfig,
+		System:       tu.MemSystem,
+		Runner:       tu.MemRunner,
+	}
+}
+
+// installFixtures lays out one of everything that strategies install.
+func installFixtures(tempdir string) {
+	holenPath := path.Join(tempdir, "holen")
+	files := map[string]string{
+		"bin/jq--1.5":                          "12345",
+		"bin/jq--1.6":                          "1234567890",
+		"bin/app--2.0.AppImage":                "appimage",
+		"appimage/app--2.0/AppRun":             "run",
+		"pkgs/protobuf/3.0/bin/protoc":         "protoc",
+		"scripts/deploy--0.5.sh":               "#!/bin/sh",
+		"go/gotool/1.1/bin/gotool":             "go",
+		"python/pytool/0.2/bin/python":         "py",
+		"npm/nodetool/4.0/bin/nodetool":        "js",
+		"build/srctool--1.0/src/main.c":        "int main;",
+		"usage/ubuntu--20.04":                  "runtime: docker\nimage: ubuntu:20.04\n",
+		"usage/ubuntu-focal--20.04":            "runtime: docker\nimage: ubuntu:20.04\n",
+		"usage/jq--1.6":                        "",
+		"bin/not-a-version":                    "x",
+		"pkgs/protobuf/3.0/include/any.proto":  "proto",
+		"appimage/app--2.0/usr/share/app.desc": "desc",
+	}
+
+	for file, contents := range files {
+		os.MkdirAll(path.Dir(path.Join(holenPath, file)), 0755)
+		ioutil.WriteFile(path.Join(holenPath, file), []byte(contents), 0644)
+	}
+}
+
+func TestInventoryFind(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	installFixtures(tempdir)
+
+	_, inv := newInventory(tempdir)
+	installed, err := inv.Find()
+	assert.Nil(err)
+
+	type summary struct {
+		key        string
+		strategies []string
+		paths      int
+		size       int64
+		image      string
+	}
+	summaries := []summary{}
+	for _, iv := range installed {
+		summaries = append(summaries, summary{iv.Key(), iv.Strategies, len(iv.Paths), iv.Size, iv.Image})
+	}
+
+	assert.Equal([]summary{
+		{"app--2.0", []string{"appimage"}, 2, 15, ""},
+		{"deploy--0.5", []string{"script"}, 1, 9, ""},
+		{"gotool--1.1", []string{"go"}, 1, 2, ""},
+		{"jq--1.5", []string{"binary"}, 1, 5, ""},
+		{"jq--1.6", []string{"binary"}, 1, 10, ""},
+		{"nodetool--4.0", []string{"npm"}, 1, 2, ""},
+		{"protobuf--3.0", []string{"binary"}, 1, 11, ""},
+		{"pytool--0.2", []string{"python"}, 1, 2, ""},
+		{"srctool--1.0", []string{"source"}, 1, 9, ""},
+		{"ubuntu--20.04", []string{"docker"}, 0, 0, "ubuntu:20.04"},
+		{"ubuntu-focal--20.04", []string{"docker"}, 0, 0, "ubuntu:20.04"},
+	}, summaries)
+
+	for _, iv := range installed {
+		if iv.Key() == "jq--1.6" {
+			assert.False(iv.LastUsed.IsZero())
+		} else if iv.Key() == "jq--1.5" {
+			assert.True(iv.LastUsed.IsZero())
+			assert.Equal(iv.Installed, iv.LastActive())
+		}
+	}
+}
+
+func TestInventoryUninstall(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	holenPath := path.Join(tempdir, "holen")
+
+	var uninstallTests = []struct {
+		utility NameVer
+		dryRun  bool
+		err     string
+		output  []string
+		gone    []string
+		kept    []string
+		history []string
+	}{
+		{
+			NameVer{"jq", ""},
+			true,
+			"",
+			[]string{
+				"Would remove jq 1.5 (binary, 5B)\n",
+				"Would remove jq 1.6 (binary, 10B)\n",
+				"Would free 15B\n",
+			},
+			[]string{},
+			[]string{"bin/jq--1.5", "bin/jq--1.6", "usage/jq--1.6"},
+			nil,
+		},
+		{
+			NameVer{"jq", "1.6"},
+			false,
+			"",
+			[]string{
+				"Removed jq 1.6 (binary, 10B)\n",
+				"Freed 10B\n",
+			},
+			[]string{"bin/jq--1.6", "usage/jq--1.6"},
+			[]string{"bin/jq--1.5"},
+			nil,
+		},
+		{
+			NameVer{"protobuf", ""},
+			false,
+			"",
+			[]string{
+				"Removed protobuf 3.0 (binary, 11B)\n",
+				"Freed 11B\n",
+			},
+			[]string{"pkgs/protobuf"},
+			[]string{"pkgs"},
+			nil,
+		},
+		{
+			NameVer{"deploy", ""},
+			false,
+			"",
+			[]string{
+				"Removed deploy 0.5 (script, 9B)\n",
+				"Freed 9B\n",
+			},
+			[]string{"scripts/deploy--0.5.sh"},
+			[]string{"scripts"},
+			nil,
+		},
+		{
+			NameVer{"srctool", ""},
+			false,
+			"",
+			[]string{
+				"Removed srctool 1.0 (source, 9B)\n",
+				"Freed 9B\n",
+			},
+			[]string{"build/srctool--1.0"},
+			[]string{"build"},
+			nil,
+		},
+		{
+			NameVer{"ubuntu", "20.04"},
+			false,
+			"",
+			[]string{
+				"Removed ubuntu 20.04 (docker, 0B)\n",
+				"Freed 0B\n",
+			},
+			[]string{"usage/ubuntu--20.04"},
+			[]string{"usage/ubuntu-focal--20.04"},
+			nil,
+		},
+		{
+			NameVer{"ubuntu-focal", ""},
+			false,
+			"",
+			[]string{
+				"Removed ubuntu-focal 20.04 (docker, 0B)\n",
+				"Freed 0B\n",
+			},
+			[]string{"usage/ubuntu-focal--20.04"},
+			[]string{},
+			[]string{"docker rmi ubuntu:20.04"},
+		},
+		{
+			NameVer{"jq", "1.7"},
+			false,
+			"jq 1.7 is not installed",
+			[]string{},
+			[]string{},
+			[]string{},
+			nil,
+		},
+		{
+			NameVer{"missing", ""},
+			false,
+			"missing is not installed",
+			[]string{},
+			[]string{},
+			[]string{},
+			nil,
+		},
+	}
+
+	installFixtures(tempdir)
+	for _, test := range uninstallTests {
+		if test.utility.Name == "ubuntu" {
+			// both of these run the same image, so it's only removed
+			// along with the second one
+			installFixtures(tempdir)
+		}
+
+		tu, inv := newInventory(tempdir)
+		err := inv.Uninstall(test.utility, test.dryRun)
+		if len(test.err) > 0 {
+			assert.NotNil(err)
+			assert.Equal(test.err, err.Error())
+		} else {
+			assert.Nil(err)
+		}
+
+		assert.Equal(test.output, tu.MemSystem.StdoutMessages)
+		assert.Equal(test.history, tu.MemRunner.History)
+		for _, file := range test.gone {
+			_, err := os.Stat(path.Join(holenPath, file))
+			assert.True(os.IsNotExist(err), file)
+		}
+		for _, file := range test.kept {
+			_, err := os.Stat(path.Join(holenPath, file))
+			assert.Nil(err, file)
+		}
+	}
+}
+
+func TestInventoryGarbageCollect(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	holenPath := path.Join(tempdir, "holen")
+
+	referenced := map[string]bool{
+		"jq--1.5":             true,
+		"jq--1.6":             true,
+		"app--2.0":            true,
+		"deploy--0.5":         true,
+		"gotool--1.1":         true,
+		"nodetool--4.0":       true,
+		"protobuf--3.0":       true,
+		"pytool--0.2":         true,
+		"ubuntu-focal--20.04": true,
+	}
+
+	// only unreferenced versions
+	installFixtures(tempdir)
+	tu, inv := newInventory(tempdir)
+	assert.Nil(inv.GarbageCollect(referenced, false))
+	assert.Equal([]string{
+		"Removed srctool 1.0 (source, 9B)\n",
+		"Removed ubuntu 20.04 (docker, 0B)\n",
+		"Freed 9B\n",
+	}, tu.MemSystem.StdoutMessages)
+	assert.Nil(tu.MemRunner.History)
+
+	// versions not used recently, with jq 1.6 having just been run
+	old := time.Now().Add(-48 * time.Hour)
+	for _, file := range []string{"bin/jq--1.5", "bin/jq--1.6", "usage/ubuntu-focal--20.04"} {
+		os.Chtimes(path.Join(holenPath, file), old, old)
+	}
+	tu, inv = newInventory(tempdir)
+	tu.MemConfig.Set(false, "gc.max_age", "24h")
+	assert.Nil(inv.GarbageCollect(referenced, true))
+	assert.Contains(tu.MemSystem.StdoutMessages, "Would remove jq 1.5 (binary, 5B)\n")
+	assert.NotContains(tu.MemSystem.StdoutMessages, "Would remove jq 1.6 (binary, 10B)\n")
+
+	tu, inv = newInventory(tempdir)
+	tu.MemConfig.Set(false, "gc.max_age", "24h")
+	assert.Nil(inv.GarbageCollect(referenced, false))
+	assert.Equal([]string{
+		"Removed jq 1.5 (binary, 5B)\n",
+		"Removed ubuntu-focal 20.04 (docker, 0B)\n",
+		"Freed 5B\n",
+	}, tu.MemSystem.StdoutMessages)
+	assert.Equal([]string{"docker rmi ubuntu:20.04"}, tu.MemRunner.History)
+
+	// nothing left to do
+	tu, inv = newInventory(tempdir)
+	assert.Nil(inv.GarbageCollect(referenced, false))
+	assert.Equal([]string{"Freed 0B\n"}, tu.MemSystem.StdoutMessages)
+}
+
+func TestInventorySharedDownloadPath(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	// a download path that holen shares with other files
+	sharedPath := path.Join(tempdir, "shared")
+	os.MkdirAll(sharedPath, 0755)
+	for _, file := range []string{"jq--1.5", "other--1.0"} {
+		ioutil.WriteFile(path.Join(sharedPath, file), []byte("binary"), 0755)
+	}
+
+	tu, inv := newInventory(tempdir)
+	tu.MemConfig.Set(false, "binary.download", sharedPath)
+	sc := &StrategyCommon{System: tu.MemSystem, Logger: tu.MemLogger, ConfigGetter: tu.MemConfig}
+	sc.RecordDownload(path.Join(sharedPath, "jq--1.5"))
+
+	installed, err := inv.Find()
+	assert.Nil(err)
+	assert.Len(installed, 1)
+	assert.Equal("jq--1.5", installed[0].Key())
+
+	assert.Nil(inv.GarbageCollect(map[string]bool{}, false))
+	assert.Equal([]string{
+		"Removed jq 1.5 (binary, 6B)\n",
+		"Freed 6B\n",
+	}, tu.MemSystem.StdoutMessages)
+
+	_, err = os.Stat(path.Join(sharedPath, "other--1.0"))
+	assert.Nil(err)
+	assert.False(downloaded(tu.MemSystem, "jq--1.5"))
+}
+
+func TestRecordUsage(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, _ := newInventory(tempdir)
+	sc := &StrategyCommon{System: tu.MemSystem, Logger: tu.MemLogger, ConfigGetter: tu.MemConfig}
+
+	record, lastUsed := readUsage(tu.MemSystem, "ubuntu", "20.04")
+	assert.Equal(UsageRecord{}, record)
+	assert.True(lastUsed.IsZero())
+
+	sc.RecordUsage("ubuntu", "20.04", "podman", "ubuntu:20.04")
+	record, lastUsed = readUsage(tu.MemSystem, "ubuntu", "20.04")
+	assert.Equal(UsageRecord{"podman", "ubuntu:20.04"}, record)
+	assert.WithinDuration(time.Now(), lastUsed, time.Minute)
+
+	// later runs without an image keep the one that was recorded
+	old := time.Now().Add(-48 * time.Hour)
+	recordPath, _ := usagePath(tu.MemSystem, "ubuntu", "20.04")
+	os.Chtimes(recordPath, old, old)
+	sc.RecordUsage("ubuntu", "20.04", "", "")
+	record, lastUsed = readUsage(tu.MemSystem, "ubuntu", "20.04")
+	assert.Equal(UsageRecord{"podman", "ubuntu:20.04"}, record)
+	assert.WithinDuration(time.Now(), lastUsed, time.Minute)
+
+	// nothing is recorded without a version
+	sc.RecordUsage("system", "", "", "")
+	entries, _ := ioutil.ReadDir(path.Join(tempdir, "holen", "usage"))
+	assert.Len(entries, 1)
+}
+
+func TestInventoryAddImages(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	installFixtures(tempdir)
+
+	tu, inv := newInventory(tempdir)
+	tu.MemRunner.Outputs = map[string]string{
+		"docker image inspect --format {{.Size}} ubuntu:20.04": "72800000\n",
+		"podman image inspect --format {{.Size}} jq:1.5":       "4000000\n",
+	}
+	tu.MemRunner.FailCmds = map[string]error{
+		"docker image inspect --format {{.Size}} util1:1.6": fmt.Errorf("no such image"),
+	}
+
+	installed, err := inv.Find()
+	assert.Nil(err)
+	installed = inv.AddImages(installed, []*InstalledVersion{
+		{Name: "jq", Version: "1.5", Runtime: "podman", Image: "jq:1.5"},
+		{Name: "util1", Version: "1.6", Runtime: "docker", Image: "util1:1.6"},
+		{Name: "ubuntu", Version: "20.04", Runtime: "docker", Image: "ubuntu:other"},
+	})
+
+	byKey := make(map[string]*InstalledVersion)
+	for _, iv := range installed {
+		byKey[iv.Key()] = iv
+	}
+
+	assert.NotContains(byKey, "util1--1.6")
+	assert.Equal([]string{"binary", "docker"}, byKey["jq--1.5"].Strategies)
+	assert.Equal(int64(4000005), byKey["jq--1.5"].Size)
+	assert.Equal("ubuntu:20.04", byKey["ubuntu--20.04"].Image)
+	assert.Equal(int64(72800000), byKey["ubuntu--20.04"].Size)
+	assert.Equal("app--2.0", installed[0].Key())
+}
+
+func TestInventoryReport(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	installed := []*InstalledVersion{
+		{
+			Name:       "jq",
+			Version:    "1.6",
+			Strategies: []string{"binary"},
+			Paths:      []string{"/data/holen/bin/jq--1.6"},
+			Size:       3 << 20,
+			Installed:  time.Date(2026, 9, 1, 10, 30, 0, 0, time.Local),
+			LastUsed:   time.Date(2026, 10, 2, 8, 0, 0, 0, time.Local),
+		},
+		{
+			Name:       "ocitool",
+			Version:    "1.0",
+			Strategies: []string{"binary"},
+			Paths:      []string{"/data/holen/bin/ocitool--1.0"},
+			Size:       2048,
+			Installed:  time.Date(2026, 9, 1, 10, 30, 0, 0, time.Local),
+		},
+		{
+			Name:       "ubuntu",
+			Version:    "18.04",
+			Strategies: []string{"docker"},
+			Runtime:    "docker",
+			Image:      "ubuntu:18.04",
+			LastUsed:   time.Date(2026, 10, 2, 8, 0, 0, 0, time.Local),
+		},
+	}
+	manifestVersions := map[string][]string{
+		"jq--1.6":      []string{"binary", "docker"},
+		"ocitool--1.0": []string{"oci"},
+	}
+
+	tu, inv := newInventory(tempdir)
+	assert.Nil(inv.Report(installed, manifestVersions, textOutput))
+	assert.Equal([]string{strings.Join([]string{
+		"NAME     VERSION  STRATEGY  SIZE  INSTALLED         LAST RUN          IN MANIFEST",
+		"jq       1.6      binary    3.0M  2026-09-01 10:30  2026-10-02 08:00  yes",
+		"ocitool  1.0      oci       2.0K  2026-09-01 10:30  never             yes",
+		"ubuntu   18.04    docker    0B    -                 2026-10-02 08:00  no",
+		"",
+	}, "\n")}, tu.MemSystem.StdoutMessages)
+
+	tu, inv = newInventory(tempdir)
+	assert.Nil(inv.Report(installed, manifestVersions, jsonOutput))
+
+	var reports []map[string]interface{}
+	assert.Nil(json.Unmarshal([]byte(tu.MemSystem.StdoutMessages[0]), &reports))
+	assert.Len(reports, 3)
+	assert.Equal(map[string]interface{}{
+		"name":        "ubuntu",
+		"version":     "18.04",
+		"strategies":  []interface{}{"docker"},
+		"size":        float64(0),
+		"installed":   nil,
+		"last_run":    time.Date(2026, 10, 2, 8, 0, 0, 0, time.Local).UTC().Format(time.RFC3339),
+		"in_manifest": false,
+		"paths":       []interface{}{},
+		"image":       "ubuntu:18.04",
+	}, reports[2])
+	assert.Equal("jq", reports[0]["name"])
+	assert.Equal([]interface{}{"/data/holen/bin/jq--1.6"}, reports[0]["paths"])
+	assert.Equal(float64(3<<20), reports[0]["size"])
+	assert.NotContains(reports[0], "image")
+}
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
@@ -117,26 +118,62 @@ exec holen run{{ .VersionFlag }} {{ .Name }} -- "$@"
 func removeOldLink(fullPath string) error {
 	fileStat, err := os.Lstat(fullPath)
 	if err == nil {
+		if isHolenLink(fullPath) {
+			os.Remove(fullPath)
+			return nil
+		}
 		if fileStat.Mode()&os.ModeSymlink != 0 {
-			target, _ := os.Readlink(fullPath)
-
-			// TODO: check more thoroughly for links that are created by holen
-			if strings.HasSuffix(target, "holen") || strings.HasSuffix(target, ".yaml") {
-				os.Remove(fullPath)
-				return nil
-			}
 			return fmt.Errorf("non-holen symlink found at %s", fullPath)
-		} else if fileStat.Mode().IsRegular() {
-			if fileStat.Size() < 500 {
-				data, _ := ioutil.ReadFile(fullPath)
-				if strings.Contains(string(data), "holen run") {
-					os.Remove(fullPath)
-					return nil
-				}
-			}
 		}
 		return fmt.Errorf("non-holen file found at %s", fullPath)
 	}
 
 	return nil
 }
+
+// isHolenLink reports whether fullPath is a symlink or script created by one
+// of the linkers above.
+func isHolenLink(fullPath string) bool {
+	fileStat, err := os.Lstat(fullPath)
+	if err != nil {
+		return false
+	}
+
+	if fileStat.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(fullPath)
+
+		// TODO: check more thoroughly for links that are created by holen
+		return strings.HasSuffix(target, "holen") || strings.HasSuffix(target, ".yaml")
+	} else if fileStat.Mode().IsRegular() && fileStat.Size() < 500 {
+		data, _ := ioutil.ReadFile(fullPath)
+		return strings.Contains(string(data), "holen run")
+	}
+
+	return false
+}
+
+// scriptVersion matches the version that a script link always runs.
+var scriptVersion = regexp.MustCompile(`holen run --version (\S+) `)
+
+// holenLinkType returns the type of link that one of the linkers above made
+// at fullPath, along with the version it always runs, if it has one.
+func holenLinkType(fullPath string) (string, string) {
+	fileStat, err := os.Lstat(fullPath)
+	if err != nil {
+		return "", ""
+	}
+
+	if fileStat.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(fullPath)
+		if strings.HasSuffix(target, ".yaml") {
+			return "manifest", ""
+		}
+		return "holen", ""
+	}
+
+	data, _ := ioutil.ReadFile(fullPath)
+	if match := scriptVersion.FindStringSubmatch(string(data)); match != nil {
+		return "script", match[1]
+	}
+	return "script", ""
+}
@@ -4,6 +4,7 @@ import "fmt"
 
 // ListCommand specifies options for the list subcommand.
 type ListCommand struct {
+	OutputOptions
 	Source string `short:"s" long:"source" description:"Only look for manifests in this source"`
 	Desc   bool   `short:"d" long:"desc" description:"Show descriptions of each utility"`
 }
@@ -12,12 +13,17 @@ var listCommand ListCommand
 
 // Listing utilities
 func (x *ListCommand) Execute(args []string) error {
+	format, err := listCommand.Format()
+	if err != nil {
+		return err
+	}
+
 	manifestFinder, err := NewManifestFinder(true)
 	if err != nil {
 		return err
 	}
 
-	return manifestFinder.List(listCommand.Source, listCommand.Desc)
+	return manifestFinder.List(listCommand.Source, listCommand.Desc, format)
 }
 
 func init() {
@@ -19,9 +19,10 @@ type GlobalOptions struct {
 
 // InlineOptions are options that are used when holen is run indirectly via a symlink.
 type InlineOptions struct {
-	Version string       `env:"HLN_VERSION" long:"hln-version" description:"Use specified version."`
-	Verbose func(string) `env:"HLN_VERBOSE" long:"hln-verbose" description:"Show verbose debug information."`
-	LogJSON func(string) `env:"HLN_LOG_JSON" long:"hln-log-json" description:"Log in JSON format."`
+	Version    string       `env:"HLN_VERSION" long:"hln-version" description:"Use specified version."`
+	DockerArgs []string     `env:"HLN_DOCKER_ARGS" env-delim:" " long:"hln-docker-arg" description:"Extra flag to pass to the container runtime. (repeatable, HLN_DOCKER_ARGS is split on spaces)"`
+	Verbose    func(string) `env:"HLN_VERBOSE" long:"hln-verbose" description:"Show verbose debug information."`
+	LogJSON    func(string) `env:"HLN_LOG_JSON" long:"hln-log-json" description:"Log in JSON format."`
 }
 
 var globalOptions GlobalOptions
@@ -93,6 +94,7 @@ func main() {
 				fmt.Println(err)
 				os.Exit(1)
 			}
+			manifest.DockerArgs = inlineOptions.DockerArgs
 
 			// fmt.Println(os.Args)
 			// fmt.Println(os.Args[2:])
@@ -154,6 +156,7 @@ func RunUtility(utility string, args []string) error {
 	if err != nil {
 		return err
 	}
+	manifest.DockerArgs = inlineOptions.DockerArgs
 
 	return manifest.Run(nameVer, args)
 }
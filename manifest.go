@@ -17,7 +17,7 @@ import (
 
 type ManifestFinder interface {
 	Find(NameVer) (*Manifest, error)
-	List(string, bool) error
+	List(string, bool, string) error
 	LinkAllUtilities(string, string, string, bool) error
 	LinkSingleUtility(string, string, string, string, bool) error
 	DefaultLinkBinPath() string
@@ -83,6 +83,16 @@ func (dmf DefaultManifestFinder) Find(utility NameVer) (*Manifest, error) {
 		}
 	}
 
+	if len(manifestPath) == 0 {
+		for _, p := range sourcePaths {
+			manifestPath = dmf.findProvider(p, utility.Name)
+			if len(manifestPath) > 0 {
+				dmf.Debugf("found manifest providing %s: %s", utility.Name, manifestPath)
+				break
+			}
+		}
+	}
+
 	if len(manifestPath) == 0 {
 		return nil, fmt.Errorf("unable to find manifest for %s", utility.Name)
 	}
@@ -90,13 +100,35 @@ func (dmf DefaultManifestFinder) Find(utility NameVer) (*Manifest, error) {
 	return LoadManifest(utility, manifestPath, dmf.ConfigGetter, dmf.Logger, dmf.System)
 }
 
-type listInfo struct {
-	name, desc string
-	count      int
+// findProvider returns the path of the first manifest in sourcePath that
+// provides command, if there is one.
+func (dmf DefaultManifestFinder) findProvider(sourcePath, command string) string {
+	var providerPath string
+	dmf.eachManifestPath(sourcePath, func(name, fileName string) error {
+		if len(providerPath) > 0 {
+			return nil
+		}
+
+		md, err := readManifestData(filepath.Join(sourcePath, fileName))
+		if err == nil && md.Provides(command) {
+			providerPath = filepath.Join(sourcePath, fileName)
+		}
+		return nil
+	})
+
+	return providerPath
+}
+
+// ListEntry is a utility as it's shown by list.  Sources names every
+// source with a manifest for it, and the first one is used.
+type ListEntry struct {
+	Name    string   `json:"name" yaml:"name"`
+	Desc    string   `json:"desc" yaml:"desc"`
+	Sources []string `json:"sources" yaml:"sources"`
 }
 
-func (dmf DefaultManifestFinder) List(source string, desc bool) error {
-	utilityInfo := make(map[string]*listInfo)
+func (dmf DefaultManifestFinder) List(source string, desc bool, format string) error {
+	utilityInfo := make(map[string]*ListEntry)
 
 	sourcePaths, err := dmf.Paths(source)
 	if err != nil {
@@ -106,14 +138,14 @@ func (dmf DefaultManifestFinder) List(source string, desc bool) error {
 	for _, p := range sourcePaths {
 		dmf.eachManifestPath(p, func(name, fileName string) error {
 			if info, ok := utilityInfo[name]; !ok {
-				info := &listInfo{name, "", 1}
+				info := &ListEntry{name, "", []string{sourceName(p)}}
 				man, err := LoadManifest(ParseName(name), filepath.Join(p, fileName), dmf.ConfigGetter, dmf.Logger, dmf.System)
 				if err == nil {
-					info.desc = man.Data.Desc
+					info.Desc = man.Data.Desc
 				}
 				utilityInfo[name] = info
 			} else {
-				info.count++
+				info.Sources = append(info.Sources, sourceName(p))
 			}
 
 			return nil
@@ -132,9 +164,17 @@ func (dmf DefaultManifestFinder) List(source string, desc bool) error {
 
 	sort.Strings(utilityNames)
 
+	if format != textOutput {
+		entries := []ListEntry{}
+		for _, name := range utilityNames {
+			entries = append(entries, *utilityInfo[name])
+		}
+		return writeStructured(dmf.System, format, entries)
+	}
+
 	for _, name := range utilityNames {
 		if desc {
-			dmf.Stdoutf("%s: %s\n", name, utilityInfo[name].desc)
+			dmf.Stdoutf("%s: %s\n", name, utilityInfo[name].Desc)
 		} else {
 			dmf.Stdoutf("%s\n", name)
 		}
@@ -143,6 +183,152 @@ func (dmf DefaultManifestFinder) List(source string, desc bool) error {
 	return nil
 }
 
+// ManifestVersions returns the strategies that give each version in the
+// manifests of every source, keyed as <name>--<version>.  Manifests that
+// can't be read are left out.
+func (dmf DefaultManifestFinder) ManifestVersions() (map[string][]string, error) {
+	versions, unreadable, err := dmf.manifestVersions()
+	for name, err := range unreadable {
+		dmf.Debugf("unable to read manifest for %s: %s", name, err)
+	}
+
+	return versions, err
+}
+
+// manifestVersions is ManifestVersions, along with why each manifest that
+// couldn't be read wasn't, keyed by utility name.
+func (dmf DefaultManifestFinder) manifestVersions() (map[string][]string, map[string]error, error) {
+	versions := make(map[string][]string)
+	unreadable := make(map[string]error)
+
+	sourcePaths, err := dmf.Paths("")
+	if err != nil {
+		return versions, unreadable, err
+	}
+
+	for _, p := range sourcePaths {
+		err = dmf.eachManifestPath(p, func(name, fileName string) error {
+			md, err := readManifestData(filepath.Join(p, fileName))
+			if err != nil {
+				unreadable[name] = err
+				return nil
+			}
+
+			for version, strategies := range md.VersionStrategies() {
+				key := fmt.Sprintf("%s--%s", name, version)
+				versions[key] = uniqueStrings(append(versions[key], strategies...))
+			}
+			return nil
+		})
+		if err != nil {
+			return versions, unreadable, errors.Wrap(err, fmt.Sprintf("unable to read manifests in %s", p))
+		}
+	}
+
+	return versions, unreadable, nil
+}
+
+// ReferencedVersions returns the versions that something still refers to,
+// keyed as <name>--<version>.  That's every version in a manifest in any
+// source, and any version that has its own link in the link bin path.  If a
+// manifest can't be read, there's no telling which of its versions are still
+// referenced, so that's an error.
+func (dmf DefaultManifestFinder) ReferencedVersions() (map[string]bool, error) {
+	referenced := make(map[string]bool)
+
+	versions, unreadable, err := dmf.manifestVersions()
+	if err != nil {
+		return referenced, err
+	}
+	if len(unreadable) > 0 {
+		problems := []string{}
+		for name, err := range unreadable {
+			problems = append(problems, fmt.Sprintf("%s (%s)", name, err))
+		}
+		sort.Strings(problems)
+		return referenced, fmt.Errorf("unable to read manifests for %s", strings.Join(problems, ", "))
+	}
+	for key := range versions {
+		referenced[key] = true
+	}
+
+	if binPath, err := homedir.Expand(dmf.DefaultLinkBinPath()); err == nil && len(binPath) > 0 {
+		links, _ := ioutil.ReadDir(binPath)
+		for _, link := range links {
+			if strings.Contains(link.Name(), "--") && isHolenLink(filepath.Join(binPath, link.Name())) {
+				referenced[link.Name()] = true
+			}
+		}
+	}
+
+	return referenced, nil
+}
+
+// DockerImages returns the image that each version of a docker strategy in
+// the manifests would run, along with the container runtime to look for it
+// with.
+func (dmf DefaultManifestFinder) DockerImages() ([]*InstalledVersion, error) {
+	images := []*InstalledVersion{}
+
+	sourcePaths, err := dmf.Paths("")
+	if err != nil {
+		return images, err
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range sourcePaths {
+		dmf.eachManifestPath(p, func(name, fileName string) error {
+			if seen[name] {
+				return nil
+			}
+			seen[name] = true
+
+			manifest, err := LoadManifest(ParseName(name), filepath.Join(p, fileName), dmf.ConfigGetter, dmf.Logger, dmf.System)
+			if err != nil {
+				return nil
+			}
+			docker, ok := manifest.Data.Strategies["docker"]
+			if !ok {
+				return nil
+			}
+			manifest.Data.Strategies = map[string]map[interface{}]interface{}{"docker": docker}
+
+			strategies, err := manifest.LoadAllStrategies(ParseName(name))
+			if err != nil {
+				dmf.Debugf("unable to load docker strategy for %s: %s", name, err)
+				return nil
+			}
+
+			for _, strategy := range strategies {
+				ds, ok := strategy.(DockerStrategy)
+				if !ok {
+					continue
+				}
+
+				containerRuntime, err := ds.Runtime()
+				if err != nil {
+					continue
+				}
+				templated, err := ds.TemplateValues(map[string]string{"Image": ds.Data.Image})
+				if err != nil {
+					continue
+				}
+
+				images = append(images, &InstalledVersion{
+					Name:       name,
+					Version:    ds.Version(),
+					Strategies: []string{"docker"},
+					Runtime:    containerRuntime,
+					Image:      templated["Image"],
+				})
+			}
+			return nil
+		})
+	}
+
+	return images, nil
+}
+
 func (dmf DefaultManifestFinder) eachManifestPath(manifestPath string, callback func(name, fileName string) error) error {
 	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
 		return nil
@@ -294,12 +480,47 @@ func (dmf DefaultManifestFinder) linkUtilities(all bool, linkType, name, source,
 	}
 
 	if !all && !linkedSingle {
+		// it might be a command provided by another utility
+		for _, manifestPath := range sourcePaths {
+			manifestPath, _ = filepath.Abs(manifestPath)
+			providerPath := dmf.findProvider(manifestPath, name)
+			if len(providerPath) == 0 {
+				continue
+			}
+
+			if linkType == "manifest" {
+				if fileLinker, ok := linker.(*FileLinker); ok {
+					fileLinker.Target = providerPath
+				}
+			}
+
+			return dmf.linkCommand(linker, name, providerPath, onlyLatest)
+		}
+
 		return fmt.Errorf("unable to find %s", name)
 	}
 	return nil
 }
 
 func (dmf DefaultManifestFinder) linkUtility(linker Linker, name, manifestPath string, onlyLatest bool) error {
+	md, err := readManifestData(manifestPath)
+	if err != nil {
+		return err
+	}
+	md.Name = name
+
+	// link the utility along with any other commands it provides
+	for _, command := range append([]string{name}, md.Commands()...) {
+		err = dmf.linkCommand(linker, command, manifestPath, onlyLatest)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (dmf DefaultManifestFinder) linkCommand(linker Linker, name, manifestPath string, onlyLatest bool) error {
 
 	if !onlyLatest {
 		// load up the manifest
@@ -326,23 +547,40 @@ func (dmf DefaultManifestFinder) linkUtility(linker Linker, name, manifestPath s
 	return linker.Link(name, "")
 }
 
-// TODO: see if this can be made a method attached to ManifestFinder
-func LoadManifest(utility NameVer, manifestPath string, conf ConfigGetter, logger Logger, system System) (*Manifest, error) {
+func readManifestData(manifestPath string) (ManifestData, error) {
 	md := ManifestData{}
 
-	logger.Debugf("attemting to load: %s", manifestPath)
 	data, err := ioutil.ReadFile(manifestPath)
 	if err != nil {
-		return nil, errors.Wrap(err, "problems with reading file")
+		return md, errors.Wrap(err, "problems with reading file")
 	}
 
 	err = yaml.Unmarshal([]byte(data), &md)
 	if err != nil {
-		return nil, errors.Wrap(err, "problems with unmarshal")
+		return md, errors.Wrap(err, "problems with unmarshal")
+	}
+
+	return md, nil
+}
+
+// TODO: see if this can be made a method attached to ManifestFinder
+func LoadManifest(utility NameVer, manifestPath string, conf ConfigGetter, logger Logger, system System) (*Manifest, error) {
+	logger.Debugf("attemting to load: %s", manifestPath)
+	md, err := readManifestData(manifestPath)
+	if err != nil {
+		return nil, err
 	}
 
 	md.Name = utility.Name
 
+	// the utility might be one of the other commands that the manifest
+	// provides, rather than the one it's named for
+	var command string
+	if name := manifestName(manifestPath); name != utility.Name && md.Provides(utility.Name) {
+		md.Name = name
+		command = utility.Name
+	}
+
 	runner := &DefaultRunner{logger}
 	manifest := &Manifest{
 		Logger:       logger,
@@ -351,6 +589,8 @@ func LoadManifest(utility NameVer, manifestPath string, conf ConfigGetter, logge
 		Runner:       runner,
 		System:       system,
 		Downloader:   &DefaultDownloader{logger, runner},
+		Command:      command,
+		Path:         manifestPath,
 	}
 	logger.Debugf("manifest found: %# v", pretty.Formatter(manifest))
 
@@ -359,11 +599,99 @@ func LoadManifest(utility NameVer, manifestPath string, conf ConfigGetter, logge
 
 type ManifestData struct {
 	Name       string
-	Desc       string `yaml:"desc"`
-	MinVersion string `yaml:"min_holen_version"`
+	Desc       string   `yaml:"desc"`
+	Homepage   string   `yaml:"homepage"`
+	License    string   `yaml:"license"`
+	MinVersion string   `yaml:"min_holen_version"`
+	Tags       []string `yaml:"tags"`
+	Keywords   []string `yaml:"keywords"`
 	Strategies map[string]map[interface{}]interface{}
 }
 
+// manifestName returns the name of the utility that a manifest is for,
+// following links to it.
+func manifestName(manifestPath string) string {
+	if resolved, err := filepath.EvalSymlinks(manifestPath); err == nil {
+		manifestPath = resolved
+	}
+
+	return strings.TrimSuffix(filepath.Base(manifestPath), ".yaml")
+}
+
+// provided returns every command listed under provides in the strategies,
+// including those only given for some versions.
+func (md ManifestData) provided() []string {
+	seen := make(map[string]bool)
+	for _, strategy := range md.Strategies {
+		data := []interface{}{strategy}
+		if versions, ok := strategy["versions"].([]interface{}); ok {
+			data = append(data, versions...)
+		}
+
+		for _, item := range data {
+			if itemMap, ok := item.(map[interface{}]interface{}); ok {
+				for command := range stringMap(itemMap["provides"]) {
+					seen[command] = true
+				}
+			}
+		}
+	}
+
+	commands := []string{}
+	for command := range seen {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	return commands
+}
+
+// VersionStrategies returns every version given by the strategies, with
+// the strategies that give it in order of name.
+func (md ManifestData) VersionStrategies() map[string][]string {
+	strategyNames := []string{}
+	for strategyName := range md.Strategies {
+		strategyNames = append(strategyNames, strategyName)
+	}
+	sort.Strings(strategyNames)
+
+	versions := make(map[string][]string)
+	for _, strategyName := range strategyNames {
+		items, _ := md.Strategies[strategyName]["versions"].([]interface{})
+		for _, item := range items {
+			if itemMap, ok := item.(map[interface{}]interface{}); ok && itemMap["version"] != nil {
+				version := fmt.Sprint(itemMap["version"])
+				versions[version] = uniqueStrings(append(versions[version], strategyName))
+			}
+		}
+	}
+
+	return versions
+}
+
+// Provides reports whether any strategy provides command.
+func (md ManifestData) Provides(command string) bool {
+	for _, provided := range md.provided() {
+		if provided == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Commands returns the commands provided besides the utility itself.
+func (md ManifestData) Commands() []string {
+	commands := []string{}
+	for _, command := range md.provided() {
+		if command != md.Name {
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
+
 type Manifest struct {
 	Logger
 	ConfigGetter
@@ -371,16 +699,49 @@ type Manifest struct {
 	System
 	Downloader
 	Data ManifestData
+
+	// DockerArgs are extra flags passed to the container runtime, given
+	// on the command line when running the utility.
+	DockerArgs []string
+
+	// Command is set when running one of the other commands that the
+	// manifest provides.  Only strategies that provide it are used.
+	Command string
+
+	// Path is where the manifest was loaded from.
+	Path string
+}
+
+// providesCommand reports whether a strategy can run the command being
+// asked for.
+func (m *Manifest) providesCommand(strategyData map[interface{}]interface{}) bool {
+	if len(m.Command) == 0 {
+		return true
+	}
+
+	_, ok := stringMap(strategyData["provides"])[m.Command]
+	return ok
 }
 
 func (m *Manifest) StrategyOrder(utility NameVer) []string {
 	// by default, higher priority is given for those that have least impact on
 	// system and can be shared:
-	//   1. cmdio - over an ssh connection, zero local footprint
+	//   1. system - already installed, nothing to fetch
 	//   2. docker - easy distribution, shared between multiple users
 	//   3. binary - static binary download
-	// Temporarily move cmdio to the last until it's GA
-	allPriorities := []string{"docker", "binary", "cmdio"}
+	//   4. appimage - self-contained linux application download
+	//   5. script - single script run with an installed interpreter
+	//   6. oci - static binary extracted from a container image
+	//   7. go - built locally with the go toolchain
+	//   8. python - installed into a virtualenv
+	//   9. npm - installed into a separate prefix
+	//  10. source - built locally from a source archive
+	//  11. ssh - run on another machine
+	//  12. cmdio - over an ssh connection, zero local footprint, but last
+	//      until it's GA
+	allPriorities := []string{"system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"}
+	// any other strategies are run by plugins, which are tried last
+	allPriorities = append(allPriorities, m.pluginStrategies(allPriorities)...)
 
 	priorities := []string{}
 
@@ -430,6 +791,25 @@ func (m *Manifest) StrategyOrder(utility NameVer) []string {
 	return priorities
 }
 
+// pluginStrategies returns the names of strategies in the manifest that
+// aren't built in, sorted so that the order is stable.
+func (m *Manifest) pluginStrategies(builtin []string) []string {
+	known := make(map[string]bool)
+	for _, name := range builtin {
+		known[name] = true
+	}
+
+	plugins := []string{}
+	for name := range m.Data.Strategies {
+		if !known[name] {
+			plugins = append(plugins, name)
+		}
+	}
+	sort.Strings(plugins)
+
+	return plugins
+}
+
 func (m *Manifest) LoadStrategies(utility NameVer) ([]Strategy, error) {
 
 	strategyOrder := m.StrategyOrder(utility)
@@ -469,6 +849,11 @@ func (m *Manifest) LoadStrategies(utility NameVer) ([]Strategy, error) {
 			final := mergeMaps(foundStrategy, selectedVersion)
 			// fmt.Printf("%v\n", final)
 
+			if !m.providesCommand(final) {
+				m.Debugf("strategy %s does not provide %s", try, m.Command)
+				continue
+			}
+
 			// handle common keys
 			var osArchData map[string]map[string]string
 			if origOsArch, osArchMapOk := final["os_arch"]; osArchMapOk {
@@ -499,6 +884,7 @@ func (m *Manifest) generateCommon() *StrategyCommon {
 		ConfigGetter: m.ConfigGetter,
 		Downloader:   m.Downloader,
 		Runner:       m.Runner,
+		ManifestPath: m.Path,
 	}
 }
 
@@ -517,6 +903,24 @@ func (m *Manifest) processOSArchMap(in interface{}) map[string]map[string]string
 	return osArchData
 }
 
+// manifestString returns a string value from the data for a strategy, or
+// an empty string if it isn't there.  Values that YAML reads as something
+// else are refused, since an unquoted version like 3.10 would come out as
+// 3.1.
+func manifestString(strategyType string, strategyData map[interface{}]interface{}, key string) (string, error) {
+	value, ok := strategyData[key]
+	if !ok || value == nil {
+		return "", nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%s %v of %s strategy must be a string, quote it in the manifest", key, value, strategyType)
+	}
+
+	return str, nil
+}
+
 func (m *Manifest) loadStrategy(strategyType string, strategyData map[interface{}]interface{}, common *StrategyCommon, osArchData map[string]map[string]string) (Strategy, error) {
 	var dummy Strategy
 
@@ -525,13 +929,18 @@ func (m *Manifest) loadStrategy(strategyType string, strategyData map[interface{
 		dockerConn, dockerConnOk := strategyData["docker_conn"]
 		interactive, interactiveOk := strategyData["interactive"]
 		pidHost, pidHostOk := strategyData["pid_host"]
+		privileged, privilegedOk := strategyData["privileged"]
 		terminal, terminalOk := strategyData["terminal"]
 		image, imageOk := strategyData["image"]
 		mountPwdAs, mountPwdAsOk := strategyData["mount_pwd_as"]
 		runAsUser, runAsUserOk := strategyData["run_as_user"]
 		pwdWorkdir, pwdWorkdirOk := strategyData["pwd_workdir"]
 		bootstrapScript, bootstrapScriptOk := strategyData["bootstrap_script"]
+		bootstrapEnv, bootstrapEnvOk := strategyData["bootstrap_env"]
 		commandRaw, commandOk := strategyData["command"]
+		network, networkOk := strategyData["network"]
+		entrypoint, entrypointOk := strategyData["entrypoint"]
+		platform, platformOk := strategyData["platform"]
 
 		if !imageOk {
 			return dummy, errors.New("At least 'image' needed for docker strategy to work")
@@ -546,6 +955,18 @@ func (m *Manifest) loadStrategy(strategyType string, strategyData map[interface{
 		if !bootstrapScriptOk {
 			bootstrapScript = ""
 		}
+		if !bootstrapEnvOk {
+			bootstrapEnv = "DOCKER_IMAGE"
+		}
+		if !networkOk {
+			network = ""
+		}
+		if !entrypointOk {
+			entrypoint = ""
+		}
+		if !platformOk {
+			platform = ""
+		}
 		command := []string{}
 		if commandOk {
 			for _, cmd := range commandRaw.([]interface{}) {
@@ -564,11 +985,20 @@ func (m *Manifest) loadStrategy(strategyType string, strategyData map[interface{
 				DockerConn:      dockerConnOk && dockerConn.(bool),
 				Interactive:     !interactiveOk || interactive.(bool),
 				PidHost:         pidHostOk && pidHost.(bool),
+				Privileged:      privilegedOk && privileged.(bool),
 				Terminal:        terminal.(string),
 				MountPwdAs:      mountPwdAs.(string),
 				RunAsUser:       runAsUserOk && runAsUser.(bool),
 				PwdWorkdir:      pwdWorkdirOk && pwdWorkdir.(bool),
 				BootstrapScript: bootstrapScript.(string),
+				BootstrapEnv:    bootstrapEnv.(string),
+				Ports:           stringSlice(strategyData["ports"]),
+				Network:         network.(string),
+				Entrypoint:      entrypoint.(string),
+				Platform:        platform.(string),
+				Platforms:       stringSlice(strategyData["platforms"]),
+				ExtraArgs:       stringSlice(strategyData["extra_args"]),
+				UserArgs:        m.DockerArgs,
 				Command:         command,
 				OSArchData:      osArchData,
 			},
@@ -576,6 +1006,9 @@ func (m *Manifest) loadStrategy(strategyType string, strategyData map[interface{
 	} else if strategyType == "binary" {
 		baseURL, baseURLOk := strategyData["base_url"]
 		unpackPath, unpackPathOk := strategyData["unpack_path"]
+		keepTree, keepTreeOk := strategyData["keep_tree"]
+		archiveFormat, archiveFormatOk := strategyData["archive_format"]
+		stripComponents, stripComponentsOk := strategyData["strip_components"]
 
 		if !baseURLOk {
 			return dummy, errors.New("At least 'base_url' needed for binary strategy to work")
@@ -583,16 +1016,243 @@ func (m *Manifest) loadStrategy(strategyType string, strategyData map[interface{
 		if !unpackPathOk {
 			unpackPath = ""
 		}
+		if keepTreeOk && keepTree.(bool) && !unpackPathOk && strategyData["provides"] == nil {
+			return dummy, errors.New("At least 'unpack_path' needed for binary strategy to keep the tree")
+		}
+		if archiveFormatOk {
+			if _, err := validFormat(archiveFormat.(string)); err != nil {
+				return dummy, err
+			}
+		} else {
+			archiveFormat = ""
+		}
+		if !stripComponentsOk {
+			stripComponents = 0
+		}
 
 		return BinaryStrategy{
 			StrategyCommon: common,
 			Data: BinaryData{
+				Name:            m.Data.Name,
+				Desc:            m.Data.Desc,
+				Version:         strategyData["version"].(string),
+				BaseURL:         baseURL.(string),
+				UnpackPath:      unpackPath.(string),
+				ArchiveFormat:   archiveFormat.(string),
+				StripComponents: stripComponents.(int),
+				Provides:        stringMap(strategyData["provides"]),
+				KeepTree:        keepTreeOk && keepTree.(bool),
+				Env:             stringMap(strategyData["env"]),
+				OSArchData:      osArchData,
+				Command:         m.Command,
+			},
+		}, nil
+	} else if strategyType == "appimage" {
+		appImageURL, appImageURLOk := strategyData["url"]
+
+		if !appImageURLOk {
+			return dummy, errors.New("At least 'url' needed for appimage strategy to work")
+		}
+
+		return AppImageStrategy{
+			StrategyCommon: common,
+			Data: AppImageData{
 				Name:       m.Data.Name,
 				Desc:       m.Data.Desc,
 				Version:    strategyData["version"].(string),
-				BaseURL:    baseURL.(string),
-				UnpackPath: unpackPath.(string),
+				URL:        appImageURL.(string),
 				OSArchData: osArchData,
+				Checksums:  checksums(strategyData),
+			},
+		}, nil
+	} else if strategyType == "oci" {
+		image, imageOk := strategyData["image"]
+		filePath, filePathOk := strategyData["path"]
+
+		if !imageOk || !filePathOk {
+			return dummy, errors.New("At least 'image' and 'path' needed for oci strategy to work")
+		}
+
+		return OCIStrategy{
+			StrategyCommon: common,
+			Data: OCIData{
+				Name:       m.Data.Name,
+				Desc:       m.Data.Desc,
+				Version:    strategyData["version"].(string),
+				Image:      image.(string),
+				Path:       filePath.(string),
+				OSArchData: osArchData,
+			},
+		}, nil
+	} else if strategyType == "go" {
+		module, moduleOk := strategyData["module"]
+		moduleVersion, moduleVersionOk := strategyData["module_version"]
+		binary, binaryOk := strategyData["binary"]
+
+		if !moduleOk {
+			return dummy, errors.New("At least 'module' needed for go strategy to work")
+		}
+		if !moduleVersionOk {
+			moduleVersion = ""
+		}
+		if !binaryOk {
+			binary = ""
+		}
+
+		return GoStrategy{
+			StrategyCommon: common,
+			Data: GoData{
+				Name:          m.Data.Name,
+				Desc:          m.Data.Desc,
+				Version:       strategyData["version"].(string),
+				Module:        module.(string),
+				ModuleVersion: moduleVersion.(string),
+				Binary:        binary.(string),
+				OSArchData:    osArchData,
+			},
+		}, nil
+	} else if strategyType == "python" {
+		values := make(map[string]string)
+		for _, key := range []string{"version", "package", "package_version", "entrypoint", "python_version"} {
+			value, err := manifestString(strategyType, strategyData, key)
+			if err != nil {
+				return dummy, err
+			}
+			values[key] = value
+		}
+
+		return PythonStrategy{
+			StrategyCommon: common,
+			Data: PythonData{
+				Name:           m.Data.Name,
+				Desc:           m.Data.Desc,
+				Version:        values["version"],
+				Package:        values["package"],
+				PackageVersion: values["package_version"],
+				Entrypoint:     values["entrypoint"],
+				PythonVersion:  values["python_version"],
+				Requirements:   stringSlice(strategyData["requirements"]),
+				OSArchData:     osArchData,
+			},
+		}, nil
+	} else if strategyType == "npm" {
+		values := make(map[string]string)
+		for _, key := range []string{"version", "package", "package_version", "bin", "integrity"} {
+			value, err := manifestString(strategyType, strategyData, key)
+			if err != nil {
+				return dummy, err
+			}
+			values[key] = value
+		}
+
+		return NpmStrategy{
+			StrategyCommon: common,
+			Data: NpmData{
+				Name:           m.Data.Name,
+				Desc:           m.Data.Desc,
+				Version:        values["version"],
+				Package:        values["package"],
+				PackageVersion: values["package_version"],
+				Bin:            values["bin"],
+				Integrity:      values["integrity"],
+				OSArchData:     osArchData,
+			},
+		}, nil
+	} else if strategyType == "system" {
+		values := make(map[string]string)
+		for _, key := range []string{"version", "executable", "version_command", "version_regex", "constraint"} {
+			value, err := manifestString(strategyType, strategyData, key)
+			if err != nil {
+				return dummy, err
+			}
+			values[key] = value
+		}
+
+		return SystemStrategy{
+			StrategyCommon: common,
+			Data: SystemData{
+				Name:           m.Data.Name,
+				Desc:           m.Data.Desc,
+				Version:        values["version"],
+				Executable:     values["executable"],
+				VersionCommand: values["version_command"],
+				VersionRegex:   values["version_regex"],
+				Constraint:     values["constraint"],
+			},
+		}, nil
+	} else if strategyType == "ssh" {
+		optional := make(map[string]string)
+		for _, key := range []string{"host", "user", "port", "identity", "command", "terminal", "remote_dir"} {
+			if value, ok := strategyData[key]; ok {
+				optional[key] = fmt.Sprintf("%v", value)
+			}
+		}
+		stdin, stdinOk := strategyData["stdin"]
+		syncPwd, syncPwdOk := strategyData["sync_pwd"]
+
+		return SSHStrategy{
+			StrategyCommon: common,
+			Data: SSHData{
+				Name:       m.Data.Name,
+				Desc:       m.Data.Desc,
+				Version:    strategyData["version"].(string),
+				Host:       optional["host"],
+				User:       optional["user"],
+				Port:       optional["port"],
+				Identity:   optional["identity"],
+				Command:    optional["command"],
+				Terminal:   optional["terminal"],
+				Stdin:      !stdinOk || stdin.(bool),
+				SyncPwd:    syncPwdOk && syncPwd.(bool),
+				RemoteDir:  optional["remote_dir"],
+				OSArchData: osArchData,
+			},
+		}, nil
+	} else if strategyType == "script" {
+		scriptURL, scriptURLOk := strategyData["url"]
+		interpreter, interpreterOk := strategyData["interpreter"]
+
+		if !scriptURLOk || !interpreterOk {
+			return dummy, errors.New("At least 'url' and 'interpreter' needed for script strategy to work")
+		}
+
+		return ScriptStrategy{
+			StrategyCommon: common,
+			Data: ScriptData{
+				Name:            m.Data.Name,
+				Desc:            m.Data.Desc,
+				Version:         strategyData["version"].(string),
+				URL:             scriptURL.(string),
+				Interpreter:     interpreter.(string),
+				InterpreterArgs: stringSlice(strategyData["interpreter_args"]),
+				OSArchData:      osArchData,
+				Checksums:       checksums(strategyData),
+			},
+		}, nil
+	} else if strategyType == "source" {
+		sourceURL, sourceURLOk := strategyData["url"]
+		artifact, artifactOk := strategyData["artifact"]
+		sourcePath, sourcePathOk := strategyData["source_path"]
+
+		if !sourceURLOk || !artifactOk {
+			return dummy, errors.New("At least 'url' and 'artifact' needed for source strategy to work")
+		}
+		if !sourcePathOk {
+			sourcePath = ""
+		}
+
+		return SourceStrategy{
+			StrategyCommon: common,
+			Data: SourceData{
+				Name:       m.Data.Name,
+				Desc:       m.Data.Desc,
+				Version:    strategyData["version"].(string),
+				URL:        sourceURL.(string),
+				SourcePath: sourcePath.(string),
+				Build:      stringSlice(strategyData["build"]),
+				Artifact:   artifact.(string),
+				OSArchData: osArchData,
+				Checksums:  checksums(strategyData),
 			},
 		}, nil
 	} else if strategyType == "cmdio" {
@@ -612,9 +1272,41 @@ func (m *Manifest) loadStrategy(strategyType string, strategyData map[interface{
 				OSArchData: osArchData,
 			},
 		}, nil
+	} else if len(strategyType) > 0 && !strings.ContainsAny(strategyType, `/\`) {
+		settings := make(map[interface{}]interface{})
+		for key, value := range strategyData {
+			if key != "version" && key != "os_arch" {
+				settings[key] = value
+			}
+		}
+
+		return PluginStrategy{
+			StrategyCommon: common,
+			Data: PluginData{
+				Name:       m.Data.Name,
+				Desc:       m.Data.Desc,
+				Version:    strategyData["version"].(string),
+				Plugin:     strategyType,
+				Settings:   jsonValue(settings).(map[string]interface{}),
+				OSArchData: osArchData,
+			},
+		}, nil
+	}
+
+	return dummy, fmt.Errorf("invalid strategy name '%s'", strategyType)
+}
+
+// checksums returns the checksums given directly in a strategy, for files
+// that are the same on every platform.
+func checksums(strategyData map[interface{}]interface{}) map[string]string {
+	sums := make(map[string]string)
+	for _, key := range []string{"sha256sum", "sha1sum", "md5sum"} {
+		if sum, ok := strategyData[key]; ok {
+			sums[key] = sum.(string)
+		}
 	}
 
-	return dummy, errors.New("No strategy type")
+	return sums
 }
 
 func (m *Manifest) LoadAllStrategies(utility NameVer) ([]Strategy, error) {
@@ -637,6 +1329,10 @@ func (m *Manifest) LoadAllStrategies(utility NameVer) ([]Strategy, error) {
 
 		for _, version := range versions {
 			final := mergeMaps(copyMap(strategy), version.(map[interface{}]interface{}))
+			if !m.providesCommand(final) {
+				continue
+			}
+
 			var osArchData map[string]map[string]string
 			if origOsArch, osArchMapOk := final["os_arch"]; osArchMapOk {
 				osArchData = m.processOSArchMap(origOsArch)
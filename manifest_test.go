@@ -87,6 +87,7 @@ func TestLoadAllStrategies(t *testing.T) {
 	assert.Nil(err)
 
 	assert.Len(allStrategies, 4)
+	assert.Equal("testdata/single/manifests/jq.yaml", allStrategies[1].(BinaryStrategy).ManifestPath)
 	assert.NotEqual(allStrategies[1].(BinaryStrategy).Data.OSArchData, allStrategies[2].(BinaryStrategy).Data.OSArchData)
 
 	assert.Equal(allStrategies[1].(BinaryStrategy).Data.OSArchData,
@@ -115,7 +116,7 @@ func TestStrategyOrder(t *testing.T) {
 		{
 			"jq",
 			func(config *MemConfig) {},
-			[]string{"docker", "binary", "cmdio"},
+			[]string{"system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 		{
 			"jq",
@@ -123,21 +124,21 @@ func TestStrategyOrder(t *testing.T) {
 				config.Set(false, "strategy.priority", "binary,docker")
 				config.Unset(false, "strategy.priority")
 			},
-			[]string{"docker", "binary", "cmdio"},
+			[]string{"system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 		{
 			"jq",
 			func(config *MemConfig) {
 				config.Set(false, "strategy.priority", "binary,docker")
 			},
-			[]string{"binary", "docker", "cmdio"},
+			[]string{"binary", "docker", "system", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 		{
 			"jq",
 			func(config *MemConfig) {
 				config.Set(false, "strategy.priority", "cmdio")
 			},
-			[]string{"cmdio", "docker", "binary"},
+			[]string{"cmdio", "system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh"},
 		},
 		{
 			"jq",
@@ -159,21 +160,21 @@ func TestStrategyOrder(t *testing.T) {
 			func(config *MemConfig) {
 				config.Set(false, "strategy.jq.xpriority", "binary")
 			},
-			[]string{"docker", "binary", "cmdio"},
+			[]string{"system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 		{
 			"jq",
 			func(config *MemConfig) {
 				config.Set(false, "strategy.jq.priority", "binary")
 			},
-			[]string{"binary", "docker", "cmdio"},
+			[]string{"binary", "system", "docker", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 		{
 			"hugo",
 			func(config *MemConfig) {
 				config.Set(false, "strategy.jq.priority", "binary")
 			},
-			[]string{"docker", "binary", "cmdio"},
+			[]string{"system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 		// test version level override and priority bump
 		{
@@ -188,21 +189,21 @@ func TestStrategyOrder(t *testing.T) {
 			func(config *MemConfig) {
 				config.Set(false, "strategy.jq.1.6.xpriority", "binary")
 			},
-			[]string{"docker", "binary", "cmdio"},
+			[]string{"system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 		{
 			"jq--1.6",
 			func(config *MemConfig) {
 				config.Set(false, "strategy.jq.1.6.priority", "binary")
 			},
-			[]string{"binary", "docker", "cmdio"},
+			[]string{"binary", "system", "docker", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 		{
 			"jq",
 			func(config *MemConfig) {
 				config.Set(false, "strategy.jq.1.6.priority", "binary")
 			},
-			[]string{"docker", "binary", "cmdio"},
+			[]string{"system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"},
 		},
 	}
 
@@ -291,13 +292,112 @@ func TestList(t *testing.T) {
 		tu, manifestFinder := newTestManifestFinder("")
 		tu.MemSourcePather.TestPaths = []string{path.Join(wd, "testdata", "single", "manifests")}
 
-		err := manifestFinder.List(test.name, test.desc)
+		err := manifestFinder.List(test.name, test.desc, textOutput)
 		assert.Nil(err)
 
 		assert.Equal(tu.MemSystem.StdoutMessages, test.result)
 	}
 }
 
+func TestListStructured(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+	tu, manifestFinder := newTestManifestFinder("")
+	tu.MemSourcePather.TestPaths = []string{
+		path.Join(wd, "testdata", "link", "manifests"),
+		path.Join(wd, "testdata", "link", "manifests2"),
+	}
+
+	assert.Nil(manifestFinder.List("", false, jsonOutput))
+	assert.Equal([]string{`[
+  {
+    "name": "util1",
+    "desc": "Test utility 1",
+    "sources": [
+      "link",
+      "manifests2"
+    ]
+  },
+  {
+    "name": "util2",
+    "desc": "Test utility 2",
+    "sources": [
+      "link"
+    ]
+  }
+]
+`}, tu.MemSystem.StdoutMessages)
+
+	tu.MemSystem.StdoutMessages = nil
+	tu.MemSourcePather.TestPaths = []string{path.Join(wd, "testdata", "single", "manifests")}
+	assert.Nil(manifestFinder.List("", false, yamlOutput))
+	assert.Equal([]string{`- name: jq
+  desc: Lightweight and flexible command-line JSON processor
+  sources:
+  - single
+`}, tu.MemSystem.StdoutMessages)
+}
+
+func TestManifestVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+	tu, manifestFinder := newTestManifestFinder("")
+	tu.MemSourcePather.TestPaths = []string{
+		path.Join(wd, "testdata", "link", "manifests"),
+		path.Join(wd, "testdata", "link", "manifests2"),
+	}
+
+	versions, err := manifestFinder.ManifestVersions()
+	assert.Nil(err)
+	assert.Equal([]string{"binary"}, versions["util1--1.5"])
+	assert.Equal([]string{"docker"}, versions["util1--1.6"])
+	assert.Equal([]string{"docker"}, versions["util1--3.6"])
+	assert.Equal([]string{"cmdio"}, versions["util2--2.0"])
+	assert.NotContains(versions, "util1--1.7")
+}
+
+func TestReferencedVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+	tempdir, _ := ioutil.TempDir("", "link")
+	defer os.RemoveAll(tempdir)
+
+	tu, manifestFinder := newTestManifestFinder("")
+	tu.MemSourcePather.TestPaths = []string{path.Join(wd, "testdata", "link", "manifests")}
+	tu.MemSystem.Setenv("HLN_LINK_BIN_PATH", tempdir)
+
+	// a version pinned by a link, and something else that isn't holen's
+	ioutil.WriteFile(path.Join(tempdir, "util3--0.9"), []byte("#!/bin/sh\nexec holen run --version 0.9 util3 -- \"$@\"\n"), 0755)
+	ioutil.WriteFile(path.Join(tempdir, "other--1.0"), []byte("#!/bin/sh\n"), 0755)
+
+	referenced, err := manifestFinder.ReferencedVersions()
+	assert.Nil(err)
+	assert.Equal(map[string]bool{
+		"util1--1.6": true,
+		"util1--1.5": true,
+		"util1--1.4": true,
+		"util2--2.0": true,
+		"util3--0.9": true,
+	}, referenced)
+
+	// a manifest that can't be read could still refer to anything
+	brokenPath := path.Join(tempdir, "broken")
+	os.MkdirAll(brokenPath, 0755)
+	ioutil.WriteFile(path.Join(brokenPath, "util4.yaml"), []byte("versions: [\n"), 0644)
+	tu.MemSourcePather.TestPaths = append(tu.MemSourcePather.TestPaths, brokenPath)
+
+	_, err = manifestFinder.ReferencedVersions()
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "unable to read manifests for util4 (problems with unmarshal")
+
+	versions, err := manifestFinder.ManifestVersions()
+	assert.Nil(err)
+	assert.Contains(versions, "util1--1.5")
+}
+
 func TestLink(t *testing.T) {
 	assert := assert.New(t)
 
@@ -502,3 +602,171 @@ func TestLink(t *testing.T) {
 // 		}
 // 	}
 // }
+
+func TestLoadPluginStrategy(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := &MemLogger{}
+	config := NewMemConfig()
+	system := NewMemSystem()
+
+	manifest, err := LoadManifest(ParseName("plugged"), "testdata/plugin/manifests/plugged.yaml", config, logger, system)
+	assert.Nil(err)
+
+	assert.Equal([]string{"system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio", "nix"}, manifest.StrategyOrder(ParseName("plugged")))
+
+	config.Set(false, "strategy.priority", "nix")
+	assert.Equal([]string{"nix", "system", "docker", "binary", "appimage", "script", "oci", "go", "python", "npm", "source", "ssh", "cmdio"}, manifest.StrategyOrder(ParseName("plugged")))
+
+	strategies, err := manifest.LoadStrategies(ParseName("plugged"))
+	assert.Nil(err)
+	assert.Len(strategies, 2)
+
+	plugin := strategies[0].(PluginStrategy)
+	assert.Equal("nix", plugin.Data.Plugin)
+	assert.Equal("2.1", plugin.Version())
+	assert.Equal(map[string]interface{}{
+		"attribute": "nixpkgs.plugged_{{.Version}}",
+		"flakes":    true,
+		"channels":  []interface{}{"nixos-{{.Version}}"},
+	}, plugin.Data.Settings)
+}
+
+func TestLoadUnquotedValues(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		strategy string
+		err      string
+	}{
+		{"system", "constraint 2 of system strategy must be a string, quote it in the manifest"},
+		{"python", "version 3.1 of python strategy must be a string, quote it in the manifest"},
+		{"npm", "package_version 1.1 of npm strategy must be a string, quote it in the manifest"},
+	}
+
+	for _, test := range tests {
+		config := NewMemConfig()
+		config.Set(false, "strategy.xpriority", test.strategy)
+
+		manifest, err := LoadManifest(ParseName("numbers"), "testdata/unquoted/manifests/numbers.yaml", config, &MemLogger{}, NewMemSystem())
+		assert.Nil(err)
+
+		_, err = manifest.LoadStrategies(ParseName("numbers"))
+		assert.EqualError(err, "error loading strategy: "+test.err)
+	}
+}
+
+func TestLoadProvidedCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := &MemLogger{}
+	config := NewMemConfig()
+	system := NewMemSystem()
+	manifestPath := "testdata/provides/manifests/protobuf.yaml"
+
+	manifest, err := LoadManifest(ParseName("protobuf"), manifestPath, config, logger, system)
+	assert.Nil(err)
+	assert.Equal("protobuf", manifest.Data.Name)
+	assert.Equal("", manifest.Command)
+	assert.Equal([]string{"protoc", "protoc-gen-doc", "protoc-gen-grpc"}, manifest.Data.Commands())
+
+	manifest, err = LoadManifest(ParseName("protoc-gen-grpc"), manifestPath, config, logger, system)
+	assert.Nil(err)
+	assert.Equal("protobuf", manifest.Data.Name)
+	assert.Equal("protoc-gen-grpc", manifest.Command)
+
+	strategies, err := manifest.LoadAllStrategies(ParseName("protoc-gen-grpc"))
+	assert.Nil(err)
+	assert.Len(strategies, 1)
+	assert.Equal("3.20", strategies[0].Version())
+	assert.Equal("protoc-gen-grpc", strategies[0].(BinaryStrategy).Data.Command)
+	assert.Equal(map[string]string{
+		"protoc":          "bin/protoc",
+		"protoc-gen-doc":  "bin/protoc-gen-doc",
+		"protoc-gen-grpc": "bin/protoc-gen-grpc",
+	}, strategies[0].(BinaryStrategy).Data.Provides)
+
+	manifest, err = LoadManifest(ParseName("protoc--3.19"), manifestPath, config, logger, system)
+	assert.Nil(err)
+	strategies, err = manifest.LoadStrategies(ParseName("protoc--3.19"))
+	assert.Nil(err)
+	assert.Len(strategies, 1)
+	assert.Equal("3.19", strategies[0].Version())
+}
+
+func TestFindProvidedCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+
+	tu, manifestFinder := newTestManifestFinder("")
+	tu.MemSourcePather.TestPaths = []string{
+		path.Join(wd, "testdata", "single", "manifests"),
+		path.Join(wd, "testdata", "provides", "manifests"),
+	}
+
+	manifest, err := manifestFinder.Find(ParseName("protoc"))
+	assert.Nil(err)
+	assert.Equal("protobuf", manifest.Data.Name)
+	assert.Equal("protoc", manifest.Command)
+
+	_, err = manifestFinder.Find(ParseName("protoc-gen-lint"))
+	assert.NotNil(err)
+	assert.Equal("unable to find manifest for protoc-gen-lint", err.Error())
+}
+
+func TestLinkProvidedCommands(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+	manifestsPath := path.Join(wd, "testdata", "provides", "manifests")
+
+	var tests = []struct {
+		link  func(*TestManifestUtils, ManifestFinder, string) error
+		links []string
+	}{
+		{
+			func(tu *TestManifestUtils, mf ManifestFinder, binPath string) error {
+				tu.MemSystem.Files[path.Join(manifestsPath, "protobuf.yaml")] = true
+				return mf.LinkSingleUtility("holen", "protobuf", "", binPath, false)
+			},
+			[]string{
+				"protobuf", "protobuf--3.19", "protobuf--3.20",
+				"protoc", "protoc--3.19", "protoc--3.20",
+				"protoc-gen-doc", "protoc-gen-doc--3.19", "protoc-gen-doc--3.20",
+				"protoc-gen-grpc", "protoc-gen-grpc--3.20",
+			},
+		},
+		{
+			func(tu *TestManifestUtils, mf ManifestFinder, binPath string) error {
+				return mf.LinkSingleUtility("holen", "protoc-gen-grpc", "", binPath, false)
+			},
+			[]string{"protoc-gen-grpc", "protoc-gen-grpc--3.20"},
+		},
+		{
+			func(tu *TestManifestUtils, mf ManifestFinder, binPath string) error {
+				return mf.LinkAllUtilities("holen", "", binPath, true)
+			},
+			[]string{"protobuf", "protoc", "protoc-gen-doc", "protoc-gen-grpc"},
+		},
+	}
+
+	for _, test := range tests {
+		tu, manifestFinder := newTestManifestFinder(path.Join(wd, "testdata", "link", "holen"))
+
+		tempdir, _ := ioutil.TempDir("", "link")
+		defer os.RemoveAll(tempdir)
+		tu.MemSourcePather.TestPaths = []string{manifestsPath}
+
+		assert.Nil(test.link(tu, manifestFinder, tempdir))
+
+		files, err := ioutil.ReadDir(tempdir)
+		assert.Nil(err)
+
+		fileNames := make([]string, len(files))
+		for i, info := range files {
+			fileNames[i] = info.Name()
+		}
+		assert.Equal(test.links, fileNames)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Structured output formats.  Text is what's shown without either flag.
+const (
+	textOutput = ""
+	jsonOutput = "json"
+	yamlOutput = "yaml"
+)
+
+// OutputOptions are the flags of the commands that can show what they
+// report as JSON or YAML instead of text.
+type OutputOptions struct {
+	JSON bool `long:"json" description:"Show as JSON"`
+	YAML bool `long:"yaml" description:"Show as YAML"`
+}
+
+// Format returns the output format asked for.
+func (oo OutputOptions) Format() (string, error) {
+	switch {
+	case oo.JSON && oo.YAML:
+		return "", errors.New("only one of --json and --yaml can be given")
+	case oo.JSON:
+		return jsonOutput, nil
+	case oo.YAML:
+		return yamlOutput, nil
+	}
+
+	return textOutput, nil
+}
+
+// writeStructured shows value as JSON or YAML.  Slices and maps should be
+// empty rather than nil, so that they aren't shown as null.
+func writeStructured(system System, format string, value interface{}) error {
+	switch format {
+	case jsonOutput:
+		data, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return err
+		}
+		system.Stdoutf("%s\n", data)
+	case yamlOutput:
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		system.Stdoutf("%s", data)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+
+	return nil
+}
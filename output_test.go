@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		options OutputOptions
+		format  string
+		err     string
+	}{
+		{OutputOptions{}, textOutput, ""},
+		{OutputOptions{JSON: true}, jsonOutput, ""},
+		{OutputOptions{YAML: true}, yamlOutput, ""},
+		{OutputOptions{JSON: true, YAML: true}, "", "only one of --json and --yaml can be given"},
+	}
+
+	for _, test := range tests {
+		format, err := test.options.Format()
+		assert.Equal(test.format, format)
+		if len(test.err) > 0 {
+			assert.EqualError(err, test.err)
+		} else {
+			assert.Nil(err)
+		}
+	}
+}
+
+func TestWriteStructured(t *testing.T) {
+	assert := assert.New(t)
+
+	value := []SourceReport{{"main", "holen-app/manifests", "git", "https://github.com/holen-app/manifests.git", "/data/main"}}
+
+	system := NewMemSystem()
+	assert.Nil(writeStructured(system, jsonOutput, value))
+	assert.Equal([]string{`[
+  {
+    "name": "main",
+    "spec": "holen-app/manifests",
+    "type": "git",
+    "url": "https://github.com/holen-app/manifests.git",
+    "local_path": "/data/main"
+  }
+]
+`}, system.StdoutMessages)
+
+	system = NewMemSystem()
+	assert.Nil(writeStructured(system, yamlOutput, value))
+	assert.Equal([]string{`- name: main
+  spec: holen-app/manifests
+  type: git
+  url: https://github.com/holen-app/manifests.git
+  local_path: /data/main
+`}, system.StdoutMessages)
+
+	system = NewMemSystem()
+	assert.EqualError(writeStructured(system, "xml", value), `unknown output format "xml"`)
+	assert.Empty(system.StdoutMessages)
+}
+
+func TestShowVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		version string
+		format  string
+		result  string
+	}{
+		{"", textOutput, "unknown version, compiled from git\n"},
+		{"1.2.3", textOutput, "version: 1.2.3\n"},
+		{"1.2.3", jsonOutput, "{\n  \"version\": \"1.2.3\"\n}\n"},
+		{"", jsonOutput, "{\n  \"version\": \"\"\n}\n"},
+		{"1.2.3", yamlOutput, "version: 1.2.3\n"},
+	}
+
+	for _, test := range tests {
+		system := NewMemSystem()
+		assert.Nil(showVersion(system, test.version, test.format))
+		assert.Equal([]string{test.result}, system.StdoutMessages)
+	}
+}
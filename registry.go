@@ -0,0 +1,363 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	mediaTypeOCIIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeOCIManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList  = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerImage = "application/vnd.docker.distribution.manifest.v2+json"
+	defaultRegistry      = "registry-1.docker.io"
+	maxManifestSize      = 4 * 1024 * 1024
+)
+
+var challengeParams = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ImageReference is a parsed image name like ghcr.io/owner/tool:1.0.
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Reference  string
+}
+
+// ParseImageReference splits image into its registry, repository and tag or
+// digest, filling in the same defaults as docker does.
+func ParseImageReference(image string) ImageReference {
+	ref := ImageReference{Registry: defaultRegistry, Reference: "latest"}
+
+	name := image
+	if at := strings.Index(name, "@"); at >= 0 {
+		ref.Reference = name[at+1:]
+		name = name[:at]
+	} else if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		ref.Reference = name[colon+1:]
+		name = name[:colon]
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry = parts[0]
+		name = parts[1]
+	}
+
+	if ref.Registry == "docker.io" || ref.Registry == "index.docker.io" {
+		ref.Registry = defaultRegistry
+	}
+
+	if ref.Registry == defaultRegistry && !strings.Contains(name, "/") {
+		name = fmt.Sprintf("library/%s", name)
+	}
+	ref.Repository = name
+
+	return ref
+}
+
+func (ir ImageReference) String() string {
+	separator := ":"
+	if strings.Contains(ir.Reference, ":") {
+		separator = "@"
+	}
+	return fmt.Sprintf("%s/%s%s%s", ir.Registry, ir.Repository, separator, ir.Reference)
+}
+
+type ociPlatform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+type ociDescriptor struct {
+	MediaType string       `json:"mediaType"`
+	Digest    string       `json:"digest"`
+	Size      int64        `json:"size"`
+	Platform  *ociPlatform `json:"platform,omitempty"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// RegistryClient talks to an OCI distribution (v2) registry over HTTP,
+// fetching anonymous bearer tokens when the registry asks for them.
+type RegistryClient struct {
+	Logger
+	Client *http.Client
+	Ref    ImageReference
+	Scheme string
+
+	// MaxSize is the most that a blob may be, or defaultMaxArchiveSize if
+	// it's zero.
+	MaxSize int64
+
+	token string
+}
+
+// NewRegistryClient returns a client for the registry hosting ref.  Plain
+// HTTP is only used for localhost and registries listed in insecure.
+func NewRegistryClient(logger Logger, ref ImageReference, insecure []string) *RegistryClient {
+	scheme := "https"
+
+	host := strings.Split(ref.Registry, ":")[0]
+	if host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	for _, registry := range insecure {
+		if strings.TrimSpace(registry) == ref.Registry {
+			scheme = "http"
+		}
+	}
+
+	return &RegistryClient{
+		Logger: logger,
+		Client: http.DefaultClient,
+		Ref:    ref,
+		Scheme: scheme,
+	}
+}
+
+func (rc *RegistryClient) get(path string, accept []string) (*http.Response, error) {
+	fullURL := fmt.Sprintf("%s://%s/v2/%s/%s", rc.Scheme, rc.Ref.Registry, rc.Ref.Repository, path)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		rc.Debugf("fetching %s", fullURL)
+
+		req, err := http.NewRequest("GET", fullURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, mediaType := range accept {
+			req.Header.Add("Accept", mediaType)
+		}
+		if len(rc.token) > 0 {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", rc.token))
+		}
+
+		res, err := rc.Client.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("unable to fetch %s", fullURL))
+		}
+
+		if res.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			res.Body.Close()
+			err = rc.authenticate(res.Header.Get("WWW-Authenticate"))
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, fmt.Errorf("unable to fetch %s: %s", fullURL, res.Status)
+		}
+
+		return res, nil
+	}
+
+	return nil, fmt.Errorf("unable to authenticate to %s", rc.Ref.Registry)
+}
+
+// authenticate fetches an anonymous token as described by a bearer
+// challenge from the registry.
+func (rc *RegistryClient) authenticate(challenge string) error {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return fmt.Errorf("registry %s requires unsupported authentication: %s", rc.Ref.Registry, challenge)
+	}
+
+	params := make(map[string]string)
+	for _, match := range challengeParams.FindAllStringSubmatch(challenge, -1) {
+		params[match[1]] = match[2]
+	}
+
+	realm, ok := params["realm"]
+	if !ok {
+		return fmt.Errorf("no realm in authentication challenge from %s", rc.Ref.Registry)
+	}
+
+	query := url.Values{}
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+	if scope, ok := params["scope"]; ok {
+		query.Set("scope", scope)
+	} else {
+		query.Set("scope", fmt.Sprintf("repository:%s:pull", rc.Ref.Repository))
+	}
+
+	rc.Debugf("fetching token from %s", realm)
+	res, err := rc.Client.Get(fmt.Sprintf("%s?%s", realm, query.Encode()))
+	if err != nil {
+		return errors.Wrap(err, "unable to fetch registry token")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to fetch registry token: %s", res.Status)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&tokenResponse)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse registry token")
+	}
+
+	rc.token = tokenResponse.Token
+	if len(rc.token) == 0 {
+		rc.token = tokenResponse.AccessToken
+	}
+
+	return nil
+}
+
+func (rc *RegistryClient) fetchManifest(reference string) (ociManifest, error) {
+	var manifest ociManifest
+
+	res, err := rc.get(fmt.Sprintf("manifests/%s", reference), []string{
+		mediaTypeOCIIndex,
+		mediaTypeOCIManifest,
+		mediaTypeDockerList,
+		mediaTypeDockerImage,
+	})
+	if err != nil {
+		return manifest, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxManifestSize))
+	if err != nil {
+		return manifest, errors.Wrap(err, "unable to read manifest")
+	}
+
+	err = json.Unmarshal(data, &manifest)
+	if err != nil {
+		return manifest, errors.Wrap(err, "unable to parse manifest")
+	}
+
+	if len(manifest.MediaType) == 0 {
+		manifest.MediaType = res.Header.Get("Content-Type")
+	}
+
+	// when fetching by digest, make sure the registry sent what was asked for
+	if strings.HasPrefix(reference, "sha256:") {
+		if sum := fmt.Sprintf("sha256:%x", sha256.Sum256(data)); sum != reference {
+			return manifest, HashMismatch{"sha256", reference, sum}
+		}
+	}
+
+	return manifest, nil
+}
+
+// ImageManifest returns the manifest of the image for the given platform,
+// choosing from the image index if the reference points to one.
+func (rc *RegistryClient) ImageManifest(osName, arch string) (ociManifest, error) {
+	manifest, err := rc.fetchManifest(rc.Ref.Reference)
+	if err != nil {
+		return manifest, err
+	}
+
+	if manifest.MediaType != mediaTypeOCIIndex && manifest.MediaType != mediaTypeDockerList && len(manifest.Manifests) == 0 {
+		return manifest, nil
+	}
+
+	for _, desc := range manifest.Manifests {
+		if desc.Platform != nil && desc.Platform.OS == osName && desc.Platform.Architecture == arch {
+			rc.Debugf("selected manifest %s for %s/%s", desc.Digest, osName, arch)
+			return rc.fetchManifest(desc.Digest)
+		}
+	}
+
+	return manifest, &SkipError{fmt.Sprintf("image %s not available for %s/%s", rc.Ref, osName, arch)}
+}
+
+// ImagePlatform returns the platform recorded in the image configuration.
+func (rc *RegistryClient) ImagePlatform(manifest ociManifest) (ociPlatform, error) {
+	var platform ociPlatform
+
+	res, err := rc.get(fmt.Sprintf("blobs/%s", manifest.Config.Digest), []string{manifest.Config.MediaType})
+	if err != nil {
+		return platform, err
+	}
+	defer res.Body.Close()
+
+	err = json.NewDecoder(io.LimitReader(res.Body, maxManifestSize)).Decode(&platform)
+	if err != nil {
+		return platform, errors.Wrap(err, "unable to parse image configuration")
+	}
+
+	return platform, nil
+}
+
+func (rc *RegistryClient) maxSize() int64 {
+	if rc.MaxSize <= 0 {
+		return defaultMaxArchiveSize
+	}
+	return rc.MaxSize
+}
+
+// DownloadBlob saves the blob described by desc to filePath, failing if the
+// content doesn't match the digest or is larger than MaxSize.  Nothing is
+// left at filePath unless it does match.
+func (rc *RegistryClient) DownloadBlob(desc ociDescriptor, filePath string) error {
+	if !strings.HasPrefix(desc.Digest, "sha256:") {
+		return fmt.Errorf("unsupported digest %s", desc.Digest)
+	}
+
+	maxSize := rc.maxSize()
+	if desc.Size > maxSize {
+		return fmt.Errorf("blob %s is %d bytes, more than the %d allowed", desc.Digest, desc.Size, maxSize)
+	}
+
+	res, err := rc.get(fmt.Sprintf("blobs/%s", desc.Digest), []string{desc.MediaType})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	partialPath := fmt.Sprintf("%s.partial", filePath)
+	out, err := os.Create(partialPath)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to create file %s", partialPath))
+	}
+	defer os.Remove(partialPath)
+	defer out.Close()
+
+	// read one byte more than allowed to tell a blob that's too large
+	hash := sha256.New()
+	written, err := io.Copy(out, io.TeeReader(io.LimitReader(res.Body, maxSize+1), hash))
+	if err != nil {
+		return errors.Wrap(err, "unable to save blob")
+	}
+	if written > maxSize {
+		return fmt.Errorf("blob %s is more than the %d bytes allowed", desc.Digest, maxSize)
+	}
+
+	if sum := fmt.Sprintf("sha256:%x", hash.Sum(nil)); sum != desc.Digest {
+		return HashMismatch{"sha256", desc.Digest, sum}
+	}
+
+	err = out.Close()
+	if err != nil {
+		return errors.Wrap(err, "unable to save blob")
+	}
+
+	return os.Rename(partialPath, filePath)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseImageReference(t *testing.T) {
+	assert := assert.New(t)
+
+	var referenceTests = []struct {
+		image  string
+		result ImageReference
+	}{
+		{
+			"alpine",
+			ImageReference{"registry-1.docker.io", "library/alpine", "latest"},
+		},
+		{
+			"alpine:3.8",
+			ImageReference{"registry-1.docker.io", "library/alpine", "3.8"},
+		},
+		{
+			"docker.io/owner/tool:1.0",
+			ImageReference{"registry-1.docker.io", "owner/tool", "1.0"},
+		},
+		{
+			"ghcr.io/owner/tool:1.0",
+			ImageReference{"ghcr.io", "owner/tool", "1.0"},
+		},
+		{
+			"localhost:5000/tool",
+			ImageReference{"localhost:5000", "tool", "latest"},
+		},
+		{
+			"quay.io/owner/tool@sha256:abcd",
+			ImageReference{"quay.io", "owner/tool", "sha256:abcd"},
+		},
+	}
+
+	for _, test := range referenceTests {
+		assert.Equal(test.result, ParseImageReference(test.image), test.image)
+	}
+
+	assert.Equal("ghcr.io/owner/tool:1.0", ParseImageReference("ghcr.io/owner/tool:1.0").String())
+	assert.Equal("quay.io/owner/tool@sha256:abcd", ParseImageReference("quay.io/owner/tool@sha256:abcd").String())
+}
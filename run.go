@@ -4,8 +4,9 @@ import "fmt"
 
 // RunCommand specifies options for the run subcommand.
 type RunCommand struct {
-	Version string `short:"v" long:"version" description:"Run this version of the utility."`
-	Args    struct {
+	Version    string   `short:"v" long:"version" description:"Run this version of the utility."`
+	DockerArgs []string `long:"docker-arg" description:"Extra flag to pass to the container runtime. (repeatable)"`
+	Args       struct {
 		Name string `description:"utility name" positional-arg-name:"<name>"`
 	} `positional-args:"yes"`
 }
@@ -25,6 +26,7 @@ func (x *RunCommand) Execute(args []string) error {
 	if err != nil {
 		return err
 	}
+	manifest.DockerArgs = runCommand.DockerArgs
 
 	return manifest.Run(nameVer, args)
 }
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// SearchResult is a manifest that matches a search, with the source it was
+// found in.
+type SearchResult struct {
+	Name       string
+	Desc       string
+	Source     string
+	Versions   int
+	Strategies []string
+	Score      int
+}
+
+// fuzzyScore rates how well query matches text, from 0 for no match.  An
+// exact match rates highest, then a prefix, then a substring, then the
+// letters of query appearing in order, less the letters skipped between.
+func fuzzyScore(query, text string) int {
+	query = strings.ToLower(query)
+	text = strings.ToLower(text)
+
+	switch {
+	case len(query) == 0 || len(text) == 0:
+		return 0
+	case text == query:
+		return 100
+	case strings.HasPrefix(text, query):
+		return 75
+	case strings.Contains(text, query):
+		return 50
+	}
+
+	letters := []rune(query)
+	matched, skipped := 0, 0
+	for _, r := range text {
+		if matched == len(letters) {
+			break
+		}
+		if r == letters[matched] {
+			matched++
+		} else if matched > 0 {
+			skipped++
+		}
+	}
+
+	if matched < len(letters) {
+		return 0
+	}
+	if skipped >= 24 {
+		return 1
+	}
+
+	return 25 - skipped
+}
+
+// searchScore rates how well a manifest matches every word of query.  Names
+// count for more than tags and keywords, and descriptions only match on
+// substrings, since nearly any description has a few letters in order.
+func searchScore(query, name string, md ManifestData) int {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return 0
+	}
+
+	total := 0
+	for _, word := range words {
+		score := fuzzyScore(word, name)
+		for _, tag := range append(append([]string{}, md.Tags...), md.Keywords...) {
+			if tagScore := fuzzyScore(word, tag) * 2 / 3; tagScore > score {
+				score = tagScore
+			}
+		}
+		if score < 20 && strings.Contains(strings.ToLower(md.Desc), strings.ToLower(word)) {
+			score = 20
+		}
+
+		if score == 0 {
+			return 0
+		}
+		total += score
+	}
+
+	return total
+}
+
+// Search finds the manifests that match query, best matches first.  Only
+// the manifest that would be used for each utility is considered.
+func (dmf DefaultManifestFinder) Search(query, source string) ([]SearchResult, error) {
+	results := []SearchResult{}
+
+	sourcePaths, err := dmf.Paths(source)
+	if err != nil {
+		return results, err
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range sourcePaths {
+		dmf.eachManifestPath(p, func(name, fileName string) error {
+			if seen[name] {
+				return nil
+			}
+			seen[name] = true
+
+			md, err := readManifestData(filepath.Join(p, fileName))
+			if err != nil {
+				dmf.Debugf("unable to read %s: %s", fileName, err)
+				return nil
+			}
+
+			score := searchScore(query, name, md)
+			if score == 0 {
+				return nil
+			}
+
+			strategies := []string{}
+			for strategy := range md.Strategies {
+				strategies = append(strategies, strategy)
+			}
+			sort.Strings(strategies)
+
+			results = append(results, SearchResult{
+				Name:       name,
+				Desc:       md.Desc,
+				Source:     sourceName(p),
+				Versions:   len(md.VersionStrategies()),
+				Strategies: strategies,
+				Score:      score,
+			})
+			return nil
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	return results, nil
+}
+
+// ShowSearch lists the manifests that match query.
+func (dmf DefaultManifestFinder) ShowSearch(query, source string) error {
+	results, err := dmf.Search(query, source)
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		dmf.Stdoutf("No utilities match %q.\n", query)
+		return nil
+	}
+
+	var table bytes.Buffer
+	writer := tabwriter.NewWriter(&table, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tSOURCE\tVERSIONS\tSTRATEGIES\tDESCRIPTION")
+	for _, result := range results {
+		fmt.Fprintf(writer, "%s\t%s\t%d\t%s\t%s\n", result.Name, result.Source, result.Versions, strings.Join(result.Strategies, ","), result.Desc)
+	}
+	writer.Flush()
+
+	dmf.Stdoutf("%s", table.String())
+	return nil
+}
+
+// SearchCommand specifies options for the search subcommand.
+type SearchCommand struct {
+	Source string `short:"s" long:"source" description:"Only look for manifests in this source"`
+	Args   struct {
+		Query []string `description:"words to search for" positional-arg-name:"<query>" required:"1"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var searchCommand SearchCommand
+
+// Searching utilities
+func (x *SearchCommand) Execute(args []string) error {
+	manifestFinder, err := NewManifestFinder(true)
+	if err != nil {
+		return err
+	}
+
+	return manifestFinder.ShowSearch(strings.Join(searchCommand.Args.Query, " "), searchCommand.Source)
+}
+
+func init() {
+	_, err := parser.AddCommand("search",
+		"Search utilities by name, description and tags.",
+		"",
+		&searchCommand)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+}
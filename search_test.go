@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		query, text string
+		score       int
+	}{
+		{"jq", "jq", 100},
+		{"JQ", "jq", 100},
+		{"terra", "terraform", 75},
+		{"form", "terraform", 50},
+		{"tf", "terraform", 21},
+		{"tfm", "terraform", 19},
+		{"jq", "jless", 0},
+		{"", "jq", 0},
+		{"jq", "", 0},
+	}
+
+	for _, test := range tests {
+		assert.Equal(test.score, fuzzyScore(test.query, test.text), "%s in %s", test.query, test.text)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+
+	var tests = []struct {
+		query   string
+		source  string
+		results []string
+	}{
+		{"jq", "", []string{"jq (main)"}},
+		{"json", "", []string{"jq (main)", "yq (extra)", "jless (main)"}},
+		{"JSON pager", "", []string{"jless (main)"}},
+		{"tf", "", []string{"terraform (main)"}},
+		{"iac", "", []string{"terraform (main)"}},
+		{"nothing", "", []string{}},
+	}
+
+	for _, test := range tests {
+		tu, manifestFinder := newTestManifestFinder("")
+		tu.MemSourcePather.TestPaths = []string{
+			path.Join(wd, "testdata", "search", "main", "manifests"),
+			path.Join(wd, "testdata", "search", "extra"),
+		}
+
+		results, err := manifestFinder.Search(test.query, test.source)
+		assert.Nil(err)
+
+		found := []string{}
+		for _, result := range results {
+			found = append(found, result.Name+" ("+result.Source+")")
+		}
+		assert.Equal(test.results, found, test.query)
+	}
+}
+
+func TestShowSearch(t *testing.T) {
+	assert := assert.New(t)
+
+	wd, _ := os.Getwd()
+	tu, manifestFinder := newTestManifestFinder("")
+	tu.MemSourcePather.TestPaths = []string{
+		path.Join(wd, "testdata", "search", "main", "manifests"),
+		path.Join(wd, "testdata", "search", "extra"),
+	}
+
+	assert.Nil(manifestFinder.ShowSearch("jq", "main"))
+	assert.Equal("main", tu.MemSourcePather.Selected)
+	assert.Equal([]string{
+		"NAME  SOURCE  VERSIONS  STRATEGIES     DESCRIPTION\n" +
+			"jq    main    2         binary,docker  Lightweight and flexible command-line JSON processor\n",
+	}, tu.MemSystem.StdoutMessages)
+
+	tu.MemSystem.StdoutMessages = nil
+	assert.Nil(manifestFinder.ShowSearch("nothing", ""))
+	assert.Equal([]string{"No utilities match \"nothing\".\n"}, tu.MemSystem.StdoutMessages)
+}
@@ -10,7 +10,9 @@ type AddSourceCommand struct {
 	} `positional-args:"yes" required:"yes"`
 }
 
-type ListSourceCommand struct{}
+type ListSourceCommand struct {
+	OutputOptions
+}
 
 type UpdateSourceCommand struct {
 	Args struct {
@@ -26,6 +28,7 @@ type DeleteSourceCommand struct {
 }
 
 type ShowSourceCommand struct {
+	OutputOptions
 	Path bool `short:"p" long:"path" description:"Show local filesystem path."`
 	Spec bool `short:"s" long:"spec" description:"Show source specification."`
 	Args struct {
@@ -56,7 +59,12 @@ func (r *ListSourceCommand) Execute(args []string) error {
 		return err
 	}
 
-	return sourceManager.List()
+	format, err := r.Format()
+	if err != nil {
+		return err
+	}
+
+	return sourceManager.List(format)
 }
 
 func (r *UpdateSourceCommand) Execute(args []string) error {
@@ -83,13 +91,20 @@ func (r *ShowSourceCommand) Execute(args []string) error {
 		return err
 	}
 
-	if r.Path {
-		return sourceManager.Show(r.Args.Name, "path")
+	format, err := r.Format()
+	if err != nil {
+		return err
+	}
+
+	if format != textOutput {
+		return sourceManager.Show(r.Args.Name, "", format)
+	} else if r.Path {
+		return sourceManager.Show(r.Args.Name, "path", format)
 	} else if r.Spec {
-		return sourceManager.Show(r.Args.Name, "spec")
+		return sourceManager.Show(r.Args.Name, "spec", format)
 	}
 
-	return fmt.Errorf("please specify -p/--path or -s/--spec")
+	return fmt.Errorf("please specify -p/--path, -s/--spec, --json or --yaml")
 }
 
 func init() {
@@ -15,16 +15,18 @@ type SourcePather interface {
 type SourceManager interface {
 	SourcePather
 	Add(bool, string, string) error
-	List() error
+	List(string) error
 	Update(string) error
 	Delete(bool, string) error
-	Show(string, string) error
+	Show(string, string, string) error
 	Bootstrap() error
 }
 
 type Source interface {
 	Name() string
 	Spec() string
+	Type() string
+	URL() string
 	Info() string
 	Update(string, bool) error
 	Delete(string) error
@@ -65,8 +67,16 @@ func (gs GitSource) fullUrl() string {
 	return ""
 }
 
+func (gs GitSource) Type() string {
+	return "git"
+}
+
+func (gs GitSource) URL() string {
+	return gs.fullUrl()
+}
+
 func (gs GitSource) Info() string {
-	return fmt.Sprintf("type: git, url: %s", gs.fullUrl())
+	return fmt.Sprintf("type: %s, url: %s", gs.Type(), gs.URL())
 }
 
 func (gs GitSource) Update(base string, createOnly bool) error {
@@ -159,7 +169,20 @@ func (rsm RealSourceManager) manifestsPath() (string, error) {
 	return manifestsPath, nil
 }
 
-func (rsm RealSourceManager) List() error {
+// SourceReport is how a source is shown as JSON or YAML.
+type SourceReport struct {
+	Name      string `json:"name" yaml:"name"`
+	Spec      string `json:"spec" yaml:"spec"`
+	Type      string `json:"type" yaml:"type"`
+	URL       string `json:"url" yaml:"url"`
+	LocalPath string `json:"local_path" yaml:"local_path"`
+}
+
+func sourceReport(source Source, manifestsPath string) SourceReport {
+	return SourceReport{source.Name(), source.Spec(), source.Type(), source.URL(), source.Path(manifestsPath)}
+}
+
+func (rsm RealSourceManager) List(format string) error {
 	sources, err := rsm.getSources()
 	if err != nil {
 		return err
@@ -170,6 +193,14 @@ func (rsm RealSourceManager) List() error {
 		return err
 	}
 
+	if format != textOutput {
+		reports := []SourceReport{}
+		for _, source := range sources {
+			reports = append(reports, sourceReport(source, manifestsPath))
+		}
+		return writeStructured(rsm.System, format, reports)
+	}
+
 	for _, source := range sources {
 		rsm.Stdoutf("%s:\n spec: %s\n info: %s\n local path: %s\n", source.Name(), source.Spec(), source.Info(), source.Path(manifestsPath))
 	}
@@ -218,7 +249,8 @@ func (rsm RealSourceManager) Delete(system bool, name string) error {
 	return rsm.Unset(system, fmt.Sprintf("source.%s", name))
 }
 
-func (rsm RealSourceManager) Show(name, field string) error {
+// Show prints one field of a source, or all of them as JSON or YAML.
+func (rsm RealSourceManager) Show(name, field, format string) error {
 	source, err := rsm.getSource(name)
 	if err != nil {
 		return err
@@ -228,11 +260,16 @@ func (rsm RealSourceManager) Show(name, field string) error {
 		return fmt.Errorf("source %s not found", name)
 	}
 
+	manifestsPath, err := rsm.manifestsPath()
+	if err != nil {
+		return err
+	}
+
+	if format != textOutput {
+		return writeStructured(rsm.System, format, sourceReport(source, manifestsPath))
+	}
+
 	if field == "path" {
-		manifestsPath, err := rsm.manifestsPath()
-		if err != nil {
-			return err
-		}
 		rsm.Stdoutf("%s\n", source.Path(manifestsPath))
 	} else if field == "spec" {
 		rsm.Stdoutf("%s\n", source.Spec())
@@ -279,6 +316,16 @@ func (rsm RealSourceManager) Paths(name string) ([]string, error) {
 	return paths, nil
 }
 
+// sourceName returns the name of the source that a path returned by Paths
+// belongs to.
+func sourceName(sourcePath string) string {
+	if filepath.Base(sourcePath) == "manifests" {
+		sourcePath = filepath.Dir(sourcePath)
+	}
+
+	return filepath.Base(sourcePath)
+}
+
 // Bootstrap only updates those sources that don't exist.  This is suitable to
 // be called by every CLI to make sure the manifests are present.
 func (rsm RealSourceManager) Bootstrap() error {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -149,12 +150,62 @@ func TestSourceManagerList(t *testing.T) {
 	tu, sm := newTestSourceManager()
 
 	assert.Nil(sm.Add(false, "test", "test/repo"))
-	assert.Nil(sm.List())
+	assert.Nil(sm.List(textOutput))
 
 	assert.Contains(tu.MemSystem.StdoutMessages, "test: test/repo (git source: https://github.com/test/repo.git)\n")
 	assert.Contains(tu.MemSystem.StdoutMessages, "main: holen-app/manifests (git source: https://github.com/holen-app/manifests.git)\n")
 }
 
+func TestSourceManagerListStructured(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "list")
+	defer os.RemoveAll(tempdir)
+
+	tu, sm := newTestSourceManager()
+	tu.MemSystem.Setenv("HOME", tempdir)
+	dataPath, _ := tu.MemSystem.DataPath()
+
+	assert.Nil(sm.Add(false, "test", "test/repo"))
+	assert.Nil(sm.List(jsonOutput))
+
+	var reports []SourceReport
+	assert.Nil(json.Unmarshal([]byte(tu.MemSystem.StdoutMessages[0]), &reports))
+	assert.ElementsMatch([]SourceReport{
+		{"test", "test/repo", "git", "https://github.com/test/repo.git", filepath.Join(dataPath, "manifests", "test")},
+		{"main", "holen-app/manifests", "git", "https://github.com/holen-app/manifests.git", filepath.Join(dataPath, "manifests", "main")},
+	}, reports)
+}
+
+func TestSourceManagerShow(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "show")
+	defer os.RemoveAll(tempdir)
+
+	tu, sm := newTestSourceManager()
+	tu.MemSystem.Setenv("HOME", tempdir)
+	dataPath, _ := tu.MemSystem.DataPath()
+	mainPath := filepath.Join(dataPath, "manifests", "main")
+
+	var tests = []struct {
+		field, format string
+		result        string
+	}{
+		{"path", textOutput, mainPath + "\n"},
+		{"spec", textOutput, "holen-app/manifests\n"},
+		{"", yamlOutput, fmt.Sprintf("name: main\nspec: holen-app/manifests\ntype: git\nurl: https://github.com/holen-app/manifests.git\nlocal_path: %s\n", mainPath)},
+	}
+
+	for _, test := range tests {
+		tu.MemSystem.StdoutMessages = nil
+		assert.Nil(sm.Show("main", test.field, test.format))
+		assert.Equal([]string{test.result}, tu.MemSystem.StdoutMessages)
+	}
+
+	assert.EqualError(sm.Show("bogus", "", jsonOutput), "source bogus not found")
+}
+
 func TestSourceManagerPaths(t *testing.T) {
 	assert := assert.New(t)
 
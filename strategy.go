@@ -7,7 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/kr/pretty"
 	"github.com/pkg/errors"
@@ -15,6 +17,10 @@ import (
 
 var NoCheckSums error = fmt.Errorf("No Checksums")
 
+// defaultBootstrapMaxAge is how long an unused bootstrap script is kept
+// around before being garbage collected.
+const defaultBootstrapMaxAge = 30 * 24 * time.Hour
+
 type HashMismatch struct {
 	algo     string
 	checksum string
@@ -31,6 +37,10 @@ type StrategyCommon struct {
 	ConfigGetter
 	Downloader
 	Runner
+
+	// ManifestPath is where the manifest that the strategy came from is,
+	// so that approvals don't carry over to one from somewhere else.
+	ManifestPath string
 }
 
 func (sc *StrategyCommon) Templater(version string, osArchData map[string]map[string]string, system System) Templater {
@@ -72,9 +82,16 @@ func (sc *StrategyCommon) CommonTemplateValues(version string, osArchData map[st
 type Strategy interface {
 	Run([]string) error
 	Inspect() error
+	Inspection() (*Inspection, error)
 	Version() string
 }
 
+// Installer is implemented by strategies that can fetch a version before
+// it's run.  Install returns where the version was installed.
+type Installer interface {
+	Install() (string, error)
+}
+
 type DockerData struct {
 	Name            string
 	Desc            string
@@ -86,11 +103,20 @@ type DockerData struct {
 	Interactive     bool                         `yaml:"interactive"`
 	Terminal        string                       `yaml:"terminal"`
 	PidHost         bool                         `yaml:"pid_host"`
+	Privileged      bool                         `yaml:"privileged"`
 	RunAsUser       bool                         `yaml:"run_as_user"`
 	PwdWorkdir      bool                         `yaml:"pwd_workdir"`
 	BootstrapScript string                       `yaml:"bootstrap_script"`
+	BootstrapEnv    string                       `yaml:"bootstrap_env"`
+	Ports           []string                     `yaml:"ports"`
+	Network         string                       `yaml:"network"`
+	Entrypoint      string                       `yaml:"entrypoint"`
+	Platform        string                       `yaml:"platform"`
+	Platforms       []string                     `yaml:"platforms"`
+	ExtraArgs       []string                     `yaml:"extra_args"`
 	Command         []string                     `yaml:"command"`
 	OSArchData      map[string]map[string]string `yaml:"os_arch_map"`
+	UserArgs        []string
 }
 
 type DockerStrategy struct {
@@ -99,12 +125,18 @@ type DockerStrategy struct {
 }
 
 type BinaryData struct {
-	Name       string
-	Desc       string
-	Version    string                       `yaml:"version"`
-	BaseURL    string                       `yaml:"base_url"`
-	UnpackPath string                       `yaml:"unpack_path"`
-	OSArchData map[string]map[string]string `yaml:"os_arch_map"`
+	Name            string
+	Desc            string
+	Version         string                       `yaml:"version"`
+	BaseURL         string                       `yaml:"base_url"`
+	UnpackPath      string                       `yaml:"unpack_path"`
+	ArchiveFormat   string                       `yaml:"archive_format"`
+	StripComponents int                          `yaml:"strip_components"`
+	Provides        map[string]string            `yaml:"provides"`
+	KeepTree        bool                         `yaml:"keep_tree"`
+	Env             map[string]string            `yaml:"env"`
+	OSArchData      map[string]map[string]string `yaml:"os_arch_map"`
+	Command         string
 }
 
 type BinaryStrategy struct {
@@ -116,11 +148,48 @@ func (ds DockerStrategy) TemplateValues(values map[string]string) (map[string]st
 	return ds.CommonTemplateValues(ds.Data.Version, ds.Data.OSArchData, ds.System, values)
 }
 
+// containerRuntimes lists the supported container runtimes in the order
+// they're tried when none is configured.
+var containerRuntimes = []string{"docker", "podman", "nerdctl"}
+
+// Runtime returns the container runtime to use, either the one configured
+// with docker.runtime or the first supported runtime found on the system.
+func (ds DockerStrategy) Runtime() (string, error) {
+	if configRuntime, err := ds.Get("docker.runtime"); err == nil && len(configRuntime) > 0 {
+		supported := false
+		for _, supportedRuntime := range containerRuntimes {
+			if supportedRuntime == configRuntime {
+				supported = true
+			}
+		}
+		if !supported {
+			return "", fmt.Errorf("unsupported container runtime %s, use one of: %s", configRuntime, strings.Join(containerRuntimes, ", "))
+		}
+
+		if !ds.CheckCommand(configRuntime, []string{"version"}) {
+			ds.Debugf("skipping, %s not available", configRuntime)
+			return "", &SkipError{fmt.Sprintf("%s not available", configRuntime)}
+		}
+
+		return configRuntime, nil
+	}
+
+	for _, containerRuntime := range containerRuntimes {
+		if ds.CheckCommand(containerRuntime, []string{"version"}) {
+			ds.Debugf("using container runtime %s", containerRuntime)
+			return containerRuntime, nil
+		}
+	}
+
+	ds.Debugf("skipping, no container runtime available")
+	return "", &SkipError{"no container runtime available"}
+}
+
 func (ds DockerStrategy) Run(extraArgs []string) error {
-	// skip if docker not found
-	if !ds.CheckCommand("docker", []string{"version"}) {
-		ds.Debugf("skipping, docker not available")
-		return &SkipError{"docker not available"}
+	// skip if no container runtime found
+	containerRuntime, err := ds.Runtime()
+	if err != nil {
+		return err
 	}
 
 	templated, err := ds.TemplateValues(map[string]string{
@@ -135,13 +204,23 @@ func (ds DockerStrategy) Run(extraArgs []string) error {
 		return errors.Wrap(err, "unable to template image name")
 	}
 
+	ds.Data.Platform, err = ds.SelectPlatform()
+	if err != nil {
+		return err
+	}
+
 	// TODO: add flag to force pulling image again
-	// err = ds.PullDockerImage(image)
+	// err = ds.PullDockerImage(containerRuntime, image)
 	// if err != nil {
 	// 	return errors.Wrap(err, "can't pull image")
 	// }
 
-	command := "docker"
+	err = ds.ApprovePrivileged(ds.Data.Name, ds.Data.Version, ds.PrivilegedOptions())
+	if err != nil {
+		return err
+	}
+
+	command := containerRuntime
 	var args []string
 	var extraEnv []string
 	if len(ds.Data.BootstrapScript) > 0 {
@@ -149,29 +228,26 @@ func (ds DockerStrategy) Run(extraArgs []string) error {
 
 		args = extraArgs
 
-		// TODO: figure out how to clean up this temp dir
-		//       "defer os.RemoveAll(tempdir)" won't work because we Exec below
-		// TODO: don't put this file in /tmp, some systems have that mounted noexec
-		tempdir, err := ioutil.TempDir("", "holen")
-
-		err = ds.CommandOutputToFile("docker", []string{"run", "--rm", "-i", image, "cat", ds.Data.BootstrapScript}, filepath.Join(tempdir, "execute"))
+		command, err = ds.BootstrapScriptPath(containerRuntime, image)
 		if err != nil {
-			return err
+			return errors.Wrap(err, "unable to extract bootstrap script")
 		}
 
-		// TODO: allow env var name to be overridden
-		extraEnv = append(extraEnv, fmt.Sprintf("DOCKER_IMAGE=%s", image))
-
-		command = filepath.Join(tempdir, "execute")
-
-		err = ds.MakeExecutable(command)
+		extraEnv = append(extraEnv, fmt.Sprintf("%s=%s", ds.Data.BootstrapEnv, image))
+		// the script runs the image itself, so it has to pass the platform
+		// along for emulation to work
+		if len(ds.Data.Platform) > 0 {
+			extraEnv = append(extraEnv, fmt.Sprintf("HOLEN_DOCKER_PLATFORM=%s", ds.Data.Platform))
+		}
+	} else {
+		args, err = ds.GenerateArgs(containerRuntime, image, extraArgs)
 		if err != nil {
 			return err
 		}
-	} else {
-		args = ds.GenerateArgs(image, extraArgs)
 	}
 
+	ds.RecordUsage(ds.Data.Name, ds.Data.Version, containerRuntime, image)
+
 	err = ds.ExecCommandWithEnv(command, args, extraEnv)
 	if err != nil {
 		return errors.Wrap(err, "can't run image")
@@ -180,22 +256,332 @@ func (ds DockerStrategy) Run(extraArgs []string) error {
 	return nil
 }
 
+// Install pulls the image for this version ahead of running it, returning
+// the image name.
+func (ds DockerStrategy) Install() (string, error) {
+	containerRuntime, err := ds.Runtime()
+	if err != nil {
+		return "", err
+	}
+
+	templated, err := ds.TemplateValues(map[string]string{
+		"Image": ds.Data.Image,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to template image name")
+	}
+
+	ds.Data.Platform, err = ds.SelectPlatform()
+	if err != nil {
+		return "", err
+	}
+
+	ds.Stderrf("Pulling %s...\n", templated["Image"])
+	err = ds.pullImage(containerRuntime, templated["Image"])
+	if err != nil {
+		return "", errors.Wrap(err, "can't pull image")
+	}
+
+	return templated["Image"], nil
+}
+
+// pullImage pulls image for the platform that's been selected, if any.
+func (ds DockerStrategy) pullImage(containerRuntime, image string) error {
+	if len(ds.Data.Platform) > 0 {
+		return ds.RunCommand(containerRuntime, []string{"pull", "--platform", ds.Data.Platform, image})
+	}
+
+	return ds.PullDockerImage(containerRuntime, image)
+}
+
 func (ds DockerStrategy) Version() string {
 	return ds.Data.Version
 }
 
-func (ds DockerStrategy) GenerateArgs(image string, extraArgs []string) []string {
+// BootstrapCachePath returns the directory under the holen data path where
+// bootstrap scripts extracted from images are kept.
+func (ds DockerStrategy) BootstrapCachePath() (string, error) {
+	holenPath, err := ds.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	cachePath := filepath.Join(holenPath, "bootstrap")
+	os.MkdirAll(cachePath, 0755)
+
+	return cachePath, nil
+}
+
+// ImageDigest returns the ID of the local copy of image, pulling the image
+// first if it isn't present yet.
+func (ds DockerStrategy) ImageDigest(containerRuntime, image string) (string, error) {
+	inspectArgs := []string{"image", "inspect", "--format", "{{.Id}}", image}
+
+	digest, err := ds.CommandOutput(containerRuntime, inspectArgs)
+	if err != nil {
+		ds.Debugf("image %s not found locally, pulling", image)
+		err = ds.pullImage(containerRuntime, image)
+		if err != nil {
+			return "", errors.Wrap(err, "can't pull image")
+		}
+
+		digest, err = ds.CommandOutput(containerRuntime, inspectArgs)
+		if err != nil {
+			return "", errors.Wrap(err, "can't inspect image")
+		}
+	}
+
+	if len(digest) == 0 {
+		return "", fmt.Errorf("no digest found for image %s", image)
+	}
+
+	return digest, nil
+}
+
+// BootstrapScriptPath returns the location of the bootstrap script for
+// image, extracting it from the image the first time it's needed.  Scripts
+// are cached by image digest, so a new image always gets a fresh copy.
+func (ds DockerStrategy) BootstrapScriptPath(containerRuntime, image string) (string, error) {
+	digest, err := ds.ImageDigest(containerRuntime, image)
+	if err != nil {
+		return "", err
+	}
+
+	cachePath, err := ds.BootstrapCachePath()
+	if err != nil {
+		return "", err
+	}
+
+	digestPath := filepath.Join(cachePath, strings.Replace(digest, ":", "-", -1))
+	scriptName := strings.Replace(strings.Trim(ds.Data.BootstrapScript, "/"), "/", "_", -1)
+	scriptPath := filepath.Join(digestPath, scriptName)
+
+	// mark this entry as recently used so garbage collection leaves it alone
+	now := time.Now()
+	if ds.FileExists(scriptPath) {
+		ds.Debugf("using cached bootstrap script %s", scriptPath)
+		os.Chtimes(digestPath, now, now)
+		return scriptPath, nil
+	}
+
+	err = os.MkdirAll(digestPath, 0755)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create bootstrap cache directory")
+	}
+
+	// extract to a temporary name first so an interrupted extraction is
+	// never mistaken for a complete script
+	tempPath := fmt.Sprintf("%s.tmp", scriptPath)
+	extractArgs := []string{"run", "--rm", "-i"}
+	if len(ds.Data.Platform) > 0 {
+		extractArgs = append(extractArgs, "--platform", ds.Data.Platform)
+	}
+	extractArgs = append(extractArgs, image, "cat", ds.Data.BootstrapScript)
+	err = ds.CommandOutputToFile(containerRuntime, extractArgs, tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+
+	err = os.Rename(tempPath, scriptPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to move bootstrap script into position")
+	}
+
+	err = ds.MakeExecutable(scriptPath)
+	if err != nil {
+		return "", err
+	}
+
+	os.Chtimes(digestPath, now, now)
+	ds.CleanBootstrapCache(cachePath)
+
+	return scriptPath, nil
+}
+
+// CleanBootstrapCache removes cached bootstrap scripts for images that
+// haven't been run within the configured maximum age.
+func (ds DockerStrategy) CleanBootstrapCache(cachePath string) error {
+	maxAge := defaultBootstrapMaxAge
+	if configMaxAge, err := ds.Get("docker.bootstrap_max_age"); err == nil && len(configMaxAge) > 0 {
+		parsed, err := time.ParseDuration(configMaxAge)
+		if err != nil {
+			ds.Warnf("invalid docker.bootstrap_max_age %s, using %s", configMaxAge, maxAge)
+		} else {
+			maxAge = parsed
+		}
+	}
+
+	entries, err := ioutil.ReadDir(cachePath)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		if entry.IsDir() && entry.ModTime().Before(cutoff) {
+			ds.Debugf("removing stale bootstrap cache entry %s", entry.Name())
+			os.RemoveAll(filepath.Join(cachePath, entry.Name()))
+		}
+	}
+
+	return nil
+}
+
+// HostPlatform returns the platform of images that run natively here.
+// Containers are always Linux, even when the runtime is on another OS.
+func (ds DockerStrategy) HostPlatform() string {
+	return fmt.Sprintf("linux/%s", ds.Arch())
+}
+
+// SelectPlatform decides which platform to ask the container runtime for.
+// Images that don't list a platform matching the host are skipped unless
+// emulation was enabled with docker.emulate or docker.<name>.emulate, in
+// which case linux/amd64 (or the first listed platform) is requested.
+func (ds DockerStrategy) SelectPlatform() (string, error) {
+	if len(ds.Data.Platform) > 0 || len(ds.Data.Platforms) == 0 {
+		return ds.Data.Platform, nil
+	}
+
+	hostPlatform := ds.HostPlatform()
+	for _, platform := range ds.Data.Platforms {
+		// ignore the variant, e.g. linux/arm/v7 matches linux/arm
+		parts := strings.SplitN(platform, "/", 3)
+		if len(parts) >= 2 && fmt.Sprintf("%s/%s", parts[0], parts[1]) == hostPlatform {
+			return "", nil
+		}
+	}
+
+	emulateKeys := []string{
+		fmt.Sprintf("docker.%s.emulate", ds.Data.Name),
+		"docker.emulate",
+	}
+
+	for _, key := range emulateKeys {
+		if value, err := ds.Get(key); err == nil && len(value) > 0 {
+			if value != "true" {
+				break
+			}
+
+			for _, platform := range ds.Data.Platforms {
+				if platform == "linux/amd64" {
+					return platform, nil
+				}
+			}
+			return ds.Data.Platforms[0], nil
+		}
+	}
+
+	ds.Debugf("skipping, image supports %s but host is %s", strings.Join(ds.Data.Platforms, ", "), hostPlatform)
+	return "", &SkipError{fmt.Sprintf("image not available for %s", hostPlatform)}
+}
+
+// PrivilegedOptions returns the options requested by the manifest that give
+// the container access to the host beyond the current directory.
+func (ds DockerStrategy) PrivilegedOptions() []string {
+	var options []string
+	if ds.Data.DockerConn {
+		options = append(options, "docker_conn")
+	}
+	if ds.Data.PidHost {
+		options = append(options, "pid_host")
+	}
+	if ds.Data.Privileged {
+		options = append(options, "privileged")
+	}
+	if ds.Data.Network == "host" {
+		options = append(options, "network_host")
+	}
+	// a bootstrap script runs the container runtime itself, with whatever
+	// flags it likes
+	if len(ds.Data.BootstrapScript) > 0 {
+		options = append(options, "bootstrap_script")
+	}
+
+	// extra_args comes from the manifest too, so look for the same options
+	// being requested there
+	for i, arg := range ds.Data.ExtraArgs {
+		var next string
+		if i+1 < len(ds.Data.ExtraArgs) {
+			next = ds.Data.ExtraArgs[i+1]
+		}
+
+		switch {
+		case strings.Contains(arg, "docker.sock"):
+			options = append(options, "docker_conn")
+		case arg == "--pid=host" || (arg == "--pid" && next == "host"):
+			options = append(options, "pid_host")
+		case arg == "--privileged":
+			options = append(options, "privileged")
+		case arg == "--network=host" || arg == "--net=host" || ((arg == "--network" || arg == "--net") && next == "host"):
+			options = append(options, "network_host")
+		}
+	}
+
+	// there are too many other ways to reach the host through docker flags
+	// to look for them all, so any extra_args need approval, showing them
+	// as they are
+	if len(ds.Data.ExtraArgs) > 0 {
+		options = append(options, fmt.Sprintf("extra_args: %s", strings.Join(ds.Data.ExtraArgs, " ")))
+	}
+
+	return uniqueStrings(options)
+}
+
+// PortArgs returns the port publishing arguments, after templating each
+// port and applying any host port override set by the user with
+// docker.<name>.port.<container port>.
+func (ds DockerStrategy) PortArgs() ([]string, error) {
+	var args []string
+
+	values := make(map[string]string)
+	for i, port := range ds.Data.Ports {
+		values[fmt.Sprintf("Port%d", i)] = port
+	}
+
+	templated, err := ds.TemplateValues(values)
+	if err != nil {
+		return args, err
+	}
+
+	for i := range ds.Data.Ports {
+		port := templated[fmt.Sprintf("Port%d", i)]
+
+		hostPort := port
+		containerPort := port
+		if sep := strings.LastIndex(port, ":"); sep >= 0 {
+			hostPort = port[:sep]
+			containerPort = port[sep+1:]
+		}
+
+		overrideKey := fmt.Sprintf("docker.%s.port.%s", ds.Data.Name, strings.Split(containerPort, "/")[0])
+		if override, err := ds.Get(overrideKey); err == nil && len(override) > 0 {
+			ds.Debugf("overriding host port for %s with %s", containerPort, override)
+			hostPort = override
+		}
+
+		args = append(args, "-p", fmt.Sprintf("%s:%s", hostPort, containerPort))
+	}
+
+	return args, nil
+}
+
+// GenerateArgs returns the arguments to pass to containerRuntime to run
+// image, adjusted for the differences between the supported runtimes.
+func (ds DockerStrategy) GenerateArgs(containerRuntime, image string, extraArgs []string) ([]string, error) {
 	args := []string{"run"}
 	if ds.Data.Interactive {
 		args = append(args, "-i")
 	}
-	// TODO: prompt the user for permission to do more invasive docker binding
 	if ds.Data.DockerConn {
-		args = append(args, "-v", "/var/run/docker.sock:/var/run/docker.sock")
+		args = append(args, "-v", fmt.Sprintf("%s:/var/run/docker.sock", ds.runtimeSocket(containerRuntime)))
 	}
 	if ds.Data.PidHost {
 		args = append(args, "--pid", "host")
 	}
+	if ds.Data.Privileged {
+		args = append(args, "--privileged")
+	}
 	if len(ds.Data.MountPwdAs) > 0 {
 		wd, _ := os.Getwd()
 		args = append(args, "--volume", fmt.Sprintf("%s:%s", wd, ds.Data.MountPwdAs))
@@ -211,7 +597,12 @@ func (ds DockerStrategy) GenerateArgs(image string, extraArgs []string) []string
 		}
 	}
 	if ds.Data.RunAsUser {
-		args = append(args, "-u", fmt.Sprintf("%d:%d", ds.UID(), ds.GID()))
+		if containerRuntime == "podman" {
+			// rootless podman maps the invoking user into the container
+			args = append(args, "--userns=keep-id")
+		} else {
+			args = append(args, "-u", fmt.Sprintf("%d:%d", ds.UID(), ds.GID()))
+		}
 	}
 	if ds.Data.Terminal != "" {
 		// TODO: support 'auto' mode that autodetects if tty is present
@@ -219,36 +610,84 @@ func (ds DockerStrategy) GenerateArgs(image string, extraArgs []string) []string
 			args = append(args, "-t")
 		}
 	}
+	portArgs, err := ds.PortArgs()
+	if err != nil {
+		return args, errors.Wrap(err, "unable to template ports")
+	}
+	args = append(args, portArgs...)
+	if len(ds.Data.Network) > 0 {
+		args = append(args, "--network", ds.Data.Network)
+	}
+	if len(ds.Data.Entrypoint) > 0 {
+		args = append(args, "--entrypoint", ds.Data.Entrypoint)
+	}
+	if len(ds.Data.Platform) > 0 {
+		args = append(args, "--platform", ds.Data.Platform)
+	}
+	args = append(args, ds.Data.ExtraArgs...)
+	args = append(args, ds.Data.UserArgs...)
 	args = append(args, "--rm", image)
 	if len(ds.Data.Command) > 0 {
 		args = append(args, ds.Data.Command...)
 	}
 	args = append(args, extraArgs...)
 
-	return args
+	return args, nil
+}
+
+// runtimeSocket returns the host path of the API socket for containerRuntime.
+func (ds DockerStrategy) runtimeSocket(containerRuntime string) string {
+	if containerRuntime == "podman" {
+		if runtimeDir := ds.Getenv("XDG_RUNTIME_DIR"); len(runtimeDir) > 0 && ds.UID() != 0 {
+			return filepath.Join(runtimeDir, "podman", "podman.sock")
+		}
+		return "/run/podman/podman.sock"
+	}
+
+	return "/var/run/docker.sock"
 }
 
 func (ds DockerStrategy) Inspect() error {
+	return ds.showInspection(ds.Inspection())
+}
+
+func (ds DockerStrategy) Inspection() (*Inspection, error) {
 	templated, err := ds.TemplateValues(map[string]string{
 		"Image": ds.Data.Image,
 	})
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("error in templating docker version %s", ds.Data.Version))
+		return nil, errors.Wrap(err, fmt.Sprintf("error in templating docker version %s", ds.Data.Version))
 	}
 
-	ds.Stdoutf("Docker Strategy (version: %s):\n", ds.Data.Version)
-	ds.Stdoutf("  final image: %s\n", templated["Image"])
-	ds.Stdoutf("  final command: docker %s\n", strings.Join(ds.GenerateArgs(templated["Image"], []string{"[args]"}), " "))
+	containerRuntime, err := ds.Runtime()
+	if err != nil {
+		containerRuntime = containerRuntimes[0]
+	}
 
-	return nil
+	args, err := ds.GenerateArgs(containerRuntime, templated["Image"], []string{"[args]"})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("error in templating docker version %s", ds.Data.Version))
+	}
+
+	inspection := newInspection("docker", "Docker Strategy", ds.Data.Version)
+	inspection.Add("final_image", "final image", templated["Image"])
+	if len(ds.Data.Platforms) > 0 {
+		inspection.add("platforms", ds.Data.Platforms, fmt.Sprintf("  platforms: %s\n", strings.Join(ds.Data.Platforms, ", ")))
+	}
+	command := append([]string{containerRuntime}, args...)
+	inspection.add("final_command", command, fmt.Sprintf("  final command: %s\n", strings.Join(command, " ")))
+
+	return inspection, nil
 }
 
-func (bs BinaryStrategy) DownloadPath() (string, error) {
+// DownloadPath returns the directory that downloaded binaries are installed
+// into.
+func (sc *StrategyCommon) DownloadPath() (string, error) {
 	var downloadPath string
-	if configDownloadPath, err := bs.Get("binary.download"); err == nil && len(configDownloadPath) > 0 {
+	if configDownloadPath, err := sc.Get("binary.download"); err == nil && len(configDownloadPath) > 0 {
 		downloadPath = configDownloadPath
 	} else {
-		holenPath, err := bs.DataPath()
+		holenPath, err := sc.DataPath()
 		if err != nil {
 			return "", errors.Wrap(err, "unable to get holen data path")
 		}
@@ -259,10 +698,25 @@ func (bs BinaryStrategy) DownloadPath() (string, error) {
 	return downloadPath, nil
 }
 
-func (bs BinaryStrategy) TempPath() (string, error) {
+// MaxArchiveSize returns how much a downloaded archive may unpack to.
+func (sc *StrategyCommon) MaxArchiveSize() int64 {
+	if configMaxSize, err := sc.Get("archive.max_size"); err == nil && len(configMaxSize) > 0 {
+		maxSize, err := parseSize(configMaxSize)
+		if err == nil {
+			return maxSize
+		}
+		sc.Warnf("invalid archive.max_size %s, using %d bytes", configMaxSize, int64(defaultMaxArchiveSize))
+	}
+
+	return defaultMaxArchiveSize
+}
+
+// TempPath returns a directory for temporary files, on the same filesystem
+// as the rest of the holen data so they can be moved into place.
+func (sc *StrategyCommon) TempPath() (string, error) {
 	var tempPath string
 
-	holenPath, err := bs.DataPath()
+	holenPath, err := sc.DataPath()
 	if err != nil {
 		return "", errors.Wrap(err, "unable to get holen data path")
 	}
@@ -277,30 +731,84 @@ func (bs BinaryStrategy) TemplateValues(values map[string]string) (map[string]st
 	return bs.CommonTemplateValues(bs.Data.Version, bs.Data.OSArchData, bs.System, values)
 }
 
-func singleFileArchive(filename string) bool {
-	return (strings.HasSuffix(strings.ToLower(filename), ".bz2") &&
-		!strings.HasSuffix(strings.ToLower(filename), ".tar.bz2") &&
-		!strings.HasSuffix(strings.ToLower(filename), ".tbz2")) ||
-		(strings.HasSuffix(strings.ToLower(filename), ".gz") &&
-			!strings.HasSuffix(strings.ToLower(filename), ".tar.gz") &&
-			!strings.HasSuffix(strings.ToLower(filename), ".tgz"))
+// archiveOptions returns how the downloaded archive is unpacked, working
+// out its format unless the manifest gives it.
+func (bs BinaryStrategy) archiveOptions(archPath string) (ArchiveOptions, error) {
+	format, err := archiveFormat(archPath, bs.Data.ArchiveFormat)
+	if err != nil {
+		return ArchiveOptions{}, err
+	}
 
+	return ArchiveOptions{
+		Format:          format,
+		StripComponents: bs.Data.StripComponents,
+		MaxSize:         bs.MaxArchiveSize(),
+	}, nil
 }
 
-func (bs BinaryStrategy) Run(args []string) error {
-	templated, err := bs.TemplateValues(map[string]string{
+// values returns everything in the binary data that can be templated.
+// The paths of provided commands are keyed by "Provides." and the command.
+func (bs BinaryStrategy) values() map[string]string {
+	values := map[string]string{
 		"BaseURL":    bs.Data.BaseURL,
 		"UnpackPath": bs.Data.UnpackPath,
-	})
+	}
+	for command, providedPath := range bs.Data.Provides {
+		values[fmt.Sprintf("Provides.%s", command)] = providedPath
+	}
+
+	return values
+}
+
+func (bs BinaryStrategy) Run(args []string) error {
+	templated, err := bs.TemplateValues(bs.values())
 	if err != nil {
 		return err
 	}
 
+	if len(bs.Data.Provides) > 0 || bs.Data.KeepTree {
+		return bs.runInPackage(templated, args)
+	}
+
+	localPath, err := bs.installBinary(templated)
+	if err != nil {
+		return err
+	}
+
+	// TODO: add option to re-checksum the binary
+
+	bs.RecordUsage(bs.Data.Name, bs.Data.Version, "", "")
+	err = bs.ExecCommand(localPath, args)
+	if err != nil {
+		return errors.Wrap(err, "can't run binary")
+	}
+
+	return nil
+}
+
+// Install downloads this version, unless it's already there, and returns
+// where it was installed.
+func (bs BinaryStrategy) Install() (string, error) {
+	templated, err := bs.TemplateValues(bs.values())
+	if err != nil {
+		return "", err
+	}
+
+	if len(bs.Data.Provides) > 0 || bs.Data.KeepTree {
+		return bs.InstallPackage(templated["BaseURL"])
+	}
+
+	return bs.installBinary(templated)
+}
+
+// installBinary downloads the single binary into the download path, unless
+// it's already there, and returns its location.
+func (bs BinaryStrategy) installBinary(templated map[string]string) (string, error) {
 	dlURL := templated["BaseURL"]
 
 	downloadPath, err := bs.DownloadPath()
 	if err != nil {
-		return errors.Wrap(err, "unable to find download path")
+		return "", errors.Wrap(err, "unable to find download path")
 	}
 	binName := fmt.Sprintf("%s--%s", bs.Data.Name, bs.Data.Version)
 	localPath := filepath.Join(downloadPath, binName)
@@ -315,7 +823,7 @@ func (bs BinaryStrategy) Run(args []string) error {
 		tempPath, err := bs.TempPath()
 		tempdir, err := ioutil.TempDir(tempPath, "holen")
 		if err != nil {
-			return errors.Wrap(err, "unable to make temporary directory")
+			return "", errors.Wrap(err, "unable to make temporary directory")
 		}
 		defer os.RemoveAll(tempdir)
 		if len(templated["UnpackPath"]) > 0 {
@@ -323,29 +831,34 @@ func (bs BinaryStrategy) Run(args []string) error {
 
 			u, err := url.Parse(dlURL)
 			if err != nil {
-				return errors.Wrap(err, "unable to parse url")
+				return "", errors.Wrap(err, "unable to parse url")
 			}
 
 			fileName := filepath.Base(u.Path)
 			archPath := filepath.Join(tempdir, fileName)
 
+			bs.Stderrf("Downloading %s...\n", dlURL)
+			err = bs.DownloadFile(dlURL, archPath)
+			if err != nil {
+				return "", errors.Wrap(err, "can't download archive")
+			}
+
+			options, err := bs.archiveOptions(archPath)
+			if err != nil {
+				return "", errors.Wrap(err, "unable to unpack archive")
+			}
+
 			unpackedPath := filepath.Join(tempdir, "unpacked")
 			binPath = filepath.Join(unpackedPath, unpackPath)
-			if singleFileArchive(fileName) {
+			if singleFileFormat(options.Format) {
 				os.MkdirAll(unpackedPath, 0755)
 				unpackedPath = filepath.Join(tempdir, "unpacked", unpackPath)
 				binPath = unpackedPath
 			}
 
-			bs.Stderrf("Downloading %s...\n", dlURL)
-			err = bs.DownloadFile(dlURL, archPath)
+			err = bs.UnpackArchiveWithOptions(archPath, unpackedPath, options)
 			if err != nil {
-				return errors.Wrap(err, "can't download archive")
-			}
-
-			err = bs.UnpackArchive(archPath, unpackedPath)
-			if err != nil {
-				return errors.Wrap(err, "unable to unpack archive")
+				return "", errors.Wrap(err, "unable to unpack archive")
 			}
 
 			sumPath = archPath
@@ -356,7 +869,7 @@ func (bs BinaryStrategy) Run(args []string) error {
 			bs.Stderrf("Downloading %s...\n", dlURL)
 			err = bs.DownloadFile(dlURL, binPath)
 			if err != nil {
-				return errors.Wrap(err, "can't download binary")
+				return "", errors.Wrap(err, "can't download binary")
 			}
 		}
 
@@ -365,26 +878,163 @@ func (bs BinaryStrategy) Run(args []string) error {
 			if err == NoCheckSums {
 				bs.Debugf("skipping checksum, no checksums provided")
 			} else {
-				return errors.Wrap(err, "binary checksum failed")
+				return "", errors.Wrap(err, "binary checksum failed")
 			}
 		}
 
 		err = os.Rename(binPath, localPath)
 		if err != nil {
-			return errors.Wrap(err, "unable to move binary into position")
+			return "", errors.Wrap(err, "unable to move binary into position")
 		}
 
 		err = bs.MakeExecutable(localPath)
 		if err != nil {
-			return errors.Wrap(err, "unable to make binary executable")
+			return "", errors.Wrap(err, "unable to make binary executable")
 		}
+		bs.RecordDownload(localPath)
 
 		os.RemoveAll(tempdir)
 	}
 
-	// TODO: add option to re-checksum the binary
+	return localPath, nil
+}
 
-	err = bs.ExecCommand(localPath, args)
+// command returns the command being run, which is the utility itself
+// unless it's one of the others that the archive provides.
+func (bs BinaryStrategy) command() string {
+	if len(bs.Data.Command) > 0 {
+		return bs.Data.Command
+	}
+	return bs.Data.Name
+}
+
+// PackagePath returns where the whole unpacked archive for this version is
+// kept, so that it can be shared by all of the commands it provides.
+func (bs BinaryStrategy) PackagePath() (string, error) {
+	holenPath, err := bs.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "pkgs", bs.Data.Name, bs.Data.Version), nil
+}
+
+// InstallPackage downloads and unpacks the archive into the package path,
+// unless that's already been done, and returns the package path.
+func (bs BinaryStrategy) InstallPackage(dlURL string) (string, error) {
+	pkgPath, err := bs.PackagePath()
+	if err != nil {
+		return "", err
+	}
+
+	if bs.FileExists(pkgPath) {
+		return pkgPath, nil
+	}
+
+	tempPath, err := bs.TempPath()
+	if err != nil {
+		return "", err
+	}
+	tempdir, err := ioutil.TempDir(tempPath, "holen")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make temporary directory")
+	}
+	defer os.RemoveAll(tempdir)
+
+	u, err := url.Parse(dlURL)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to parse url")
+	}
+	archPath := filepath.Join(tempdir, filepath.Base(u.Path))
+
+	bs.Stderrf("Downloading %s...\n", dlURL)
+	err = bs.DownloadFile(dlURL, archPath)
+	if err != nil {
+		return "", errors.Wrap(err, "can't download archive")
+	}
+
+	err = bs.ChecksumBinary(archPath)
+	if err != nil {
+		if err == NoCheckSums {
+			bs.Debugf("skipping checksum, no checksums provided")
+		} else {
+			return "", errors.Wrap(err, "archive checksum failed")
+		}
+	}
+
+	options, err := bs.archiveOptions(archPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to unpack archive")
+	}
+
+	unpackedPath := filepath.Join(tempdir, "unpacked")
+	err = bs.UnpackArchiveWithOptions(archPath, unpackedPath, options)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to unpack archive")
+	}
+
+	err = os.MkdirAll(filepath.Dir(pkgPath), 0755)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make package directory")
+	}
+
+	err = os.Rename(unpackedPath, pkgPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to move package into position")
+	}
+
+	return pkgPath, nil
+}
+
+// PackageEnv returns the environment that commands are run with from the
+// package path.  Values can use {{.Root}} for the package path and refer to
+// variables that are already set, like $PATH.
+func (bs BinaryStrategy) PackageEnv(pkgPath string) ([]string, error) {
+	temp := bs.Templater(bs.Data.Version, bs.Data.OSArchData, bs.System)
+	temp.Root = pkgPath
+
+	keys := []string{}
+	for key := range bs.Data.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := []string{}
+	for _, key := range keys {
+		value, err := temp.Template(bs.Data.Env[key])
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("unable to template env %s (%s)", key, bs.Data.Env[key]))
+		}
+		env = append(env, fmt.Sprintf("%s=%s", key, os.Expand(value, bs.Getenv)))
+	}
+
+	return env, nil
+}
+
+// runInPackage runs the command in place from the package tree.
+func (bs BinaryStrategy) runInPackage(templated map[string]string, args []string) error {
+	command := bs.command()
+
+	providedPath, ok := templated[fmt.Sprintf("Provides.%s", command)]
+	if !ok && command == bs.Data.Name && len(templated["UnpackPath"]) > 0 {
+		providedPath, ok = templated["UnpackPath"], true
+	}
+	if !ok {
+		return fmt.Errorf("%s %s does not provide %s", bs.Data.Name, bs.Data.Version, command)
+	}
+
+	pkgPath, err := bs.InstallPackage(templated["BaseURL"])
+	if err != nil {
+		return err
+	}
+
+	env, err := bs.PackageEnv(pkgPath)
+	if err != nil {
+		return err
+	}
+
+	bs.RecordUsage(bs.Data.Name, bs.Data.Version, "", "")
+	err = bs.ExecCommandWithEnv(filepath.Join(pkgPath, providedPath), args, env)
 	if err != nil {
 		return errors.Wrap(err, "can't run binary")
 	}
@@ -393,29 +1043,76 @@ func (bs BinaryStrategy) Run(args []string) error {
 }
 
 func (bs BinaryStrategy) Inspect() error {
-	templated, err := bs.TemplateValues(map[string]string{
-		"BaseURL":    bs.Data.BaseURL,
-		"UnpackPath": bs.Data.UnpackPath,
-	})
+	return bs.showInspection(bs.Inspection())
+}
+
+func (bs BinaryStrategy) Inspection() (*Inspection, error) {
+	templated, err := bs.TemplateValues(bs.values())
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("error in templating binary version %s", bs.Data.Version))
+		return nil, errors.Wrap(err, fmt.Sprintf("error in templating binary version %s", bs.Data.Version))
 	}
 
-	bs.Stdoutf("Binary Strategy (version: %s):\n", bs.Data.Version)
-	bs.Stdoutf("  final url: %s\n", templated["BaseURL"])
+	inspection := newInspection("binary", "Binary Strategy", bs.Data.Version)
+	inspection.Add("final_url", "final url", templated["BaseURL"])
 	if len(templated["UnpackPath"]) > 0 {
-		bs.Stdoutf("  final unpack path: %s\n", templated["UnpackPath"])
+		inspection.Add("final_unpack_path", "final unpack path", templated["UnpackPath"])
+	}
+	if len(bs.Data.ArchiveFormat) > 0 {
+		inspection.Add("archive_format", "archive format", bs.Data.ArchiveFormat)
+	}
+	if bs.Data.StripComponents > 0 {
+		inspection.Add("strip_components", "strip components", bs.Data.StripComponents)
 	}
-	algo, sum := bs.FindChecksumAlgoAndSum()
-	if len(algo) > 0 {
-		bs.Stdoutf("  checksum with %s: %s\n", algo, sum)
+	if bs.Data.KeepTree || len(bs.Data.Provides) > 0 {
+		pkgPath, err := bs.PackagePath()
+		if err != nil {
+			return nil, err
+		}
+		inspection.Add("package_path", "package path", pkgPath)
+
+		env, err := bs.PackageEnv(pkgPath)
+		if err != nil {
+			return nil, err
+		}
+		inspection.AddEach("env", "env", env)
 	}
+	if len(bs.Data.Provides) > 0 {
+		provides := make(map[string]string)
+		for command := range bs.Data.Provides {
+			provides[command] = templated[fmt.Sprintf("Provides.%s", command)]
+		}
+		inspection.AddMap("provides", "provides", provides)
+	}
+	inspection.AddChecksum(bs.FindChecksumAlgoAndSum())
 
-	return nil
+	return inspection, nil
 }
 
 func (bs BinaryStrategy) FindChecksumAlgoAndSum() (string, string) {
-	data := bs.Data.OSArchData[fmt.Sprintf("%s_%s", bs.OS(), bs.Arch())]
+	return bs.FindChecksum(bs.Data.OSArchData)
+}
+
+func (bs BinaryStrategy) ChecksumBinary(binaryPath string) error {
+	return bs.ChecksumFile(binaryPath, bs.Data.OSArchData)
+}
+
+// ChecksumData returns checksums in the form FindChecksum expects.  Files
+// that are the same on every platform, like scripts and source archives,
+// can have their checksums given directly instead of per platform.
+func (sc *StrategyCommon) ChecksumData(checksums map[string]string, osArchData map[string]map[string]string) map[string]map[string]string {
+	if len(checksums) > 0 {
+		return map[string]map[string]string{
+			fmt.Sprintf("%s_%s", sc.OS(), sc.Arch()): checksums,
+		}
+	}
+
+	return osArchData
+}
+
+// FindChecksum returns the strongest checksum algorithm and sum listed for
+// the current OS and architecture.
+func (sc *StrategyCommon) FindChecksum(osArchData map[string]map[string]string) (string, string) {
+	data := osArchData[fmt.Sprintf("%s_%s", sc.OS(), sc.Arch())]
 
 	var checksum string
 	var ok bool
@@ -430,13 +1127,15 @@ func (bs BinaryStrategy) FindChecksumAlgoAndSum() (string, string) {
 	return "", ""
 }
 
-func (bs BinaryStrategy) ChecksumBinary(binaryPath string) error {
-	algo, checksum := bs.FindChecksumAlgoAndSum()
+// ChecksumFile verifies filePath against the checksum listed for the
+// current OS and architecture, returning NoCheckSums if there isn't one.
+func (sc *StrategyCommon) ChecksumFile(filePath string, osArchData map[string]map[string]string) error {
+	algo, checksum := sc.FindChecksum(osArchData)
 	if len(algo) == 0 {
 		return NoCheckSums
 	}
 
-	hash, err := hashFile(algo, binaryPath)
+	hash, err := hashFile(algo, filePath)
 	if err != nil {
 		return err
 	} else if hash != checksum {
@@ -471,30 +1170,46 @@ func (cs CmdioStrategy) TemplateValues(values map[string]string) (map[string]str
 	return cs.CommonTemplateValues(cs.Data.Version, cs.Data.OSArchData, cs.System, values)
 }
 
+// ssh returns the ssh strategy that runs the command on cmd.io.
+func (cs CmdioStrategy) ssh() SSHStrategy {
+	return SSHStrategy{
+		StrategyCommon: cs.StrategyCommon,
+		Data: SSHData{
+			Name:       cs.Data.Name,
+			Desc:       cs.Data.Desc,
+			Version:    cs.Data.Version,
+			Host:       "alpha.cmd.io",
+			Command:    cs.Data.Command,
+			Stdin:      true,
+			OSArchData: cs.Data.OSArchData,
+		},
+		pinned: true,
+	}
+}
+
 func (cs CmdioStrategy) Inspect() error {
-	templated, err := cs.TemplateValues(map[string]string{
-		"Command": cs.Data.Command,
-	})
+	return cs.showInspection(cs.Inspection())
+}
+
+func (cs CmdioStrategy) Inspection() (*Inspection, error) {
+	ss := cs.ssh()
+	settings, err := ss.Settings()
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("error in templating cmdio version %s", cs.Data.Version))
+		return nil, errors.Wrap(err, fmt.Sprintf("error in templating cmdio version %s", cs.Data.Version))
 	}
 
-	cs.Stdoutf("Cmd.io Strategy (version: %s):\n", cs.Data.Version)
-	cs.Stdoutf("  final command: %s\n", templated["Command"])
+	inspection := newInspection("cmdio", "Cmd.io Strategy", cs.Data.Version)
+	inspection.Add("final_command", "final command", settings["command"])
 
-	return nil
+	return inspection, nil
 }
 
 func (cs CmdioStrategy) Run(args []string) error {
-	templated, err := cs.TemplateValues(map[string]string{
-		"Command": cs.Data.Command,
-	})
-	if err != nil {
-		return err
-	}
-
-	err = cs.ExecCommand("ssh", append([]string{"alpha.cmd.io", templated["Command"]}, args...))
+	err := cs.ssh().Run(args)
 	if err != nil {
+		if _, ok := err.(*SkipError); ok {
+			return err
+		}
 		return errors.Wrap(err, "can't run cmdio session")
 	}
 
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+type AppImageData struct {
+	Name       string
+	Desc       string
+	Version    string                       `yaml:"version"`
+	URL        string                       `yaml:"url"`
+	OSArchData map[string]map[string]string `yaml:"os_arch_map"`
+	Checksums  map[string]string
+}
+
+// AppImageStrategy downloads an AppImage and runs it, falling back to
+// running it unpacked when FUSE isn't available to mount it.
+type AppImageStrategy struct {
+	*StrategyCommon
+	Data AppImageData
+}
+
+func (as AppImageStrategy) Version() string {
+	return as.Data.Version
+}
+
+func (as AppImageStrategy) TemplateValues(values map[string]string) (map[string]string, error) {
+	return as.CommonTemplateValues(as.Data.Version, as.Data.OSArchData, as.System, values)
+}
+
+func (as AppImageStrategy) templated() (map[string]string, error) {
+	templated, err := as.TemplateValues(map[string]string{
+		"URL": as.Data.URL,
+	})
+	if err != nil {
+		return templated, errors.Wrap(err, fmt.Sprintf("error in templating appimage version %s", as.Data.Version))
+	}
+
+	return templated, nil
+}
+
+// HasFUSE checks whether the AppImage can be mounted, which needs both the
+// FUSE device and fusermount.
+func (as AppImageStrategy) HasFUSE() bool {
+	if !as.FileExists("/dev/fuse") {
+		return false
+	}
+
+	pathEnv := as.Getenv("PATH")
+	return len(lookPath(pathEnv, "fusermount")) > 0 || len(lookPath(pathEnv, "fusermount3")) > 0
+}
+
+// fallback returns how to run the AppImage without FUSE, either "extract"
+// to unpack it once into the data path, or "extract-and-run" to let the
+// AppImage unpack itself every time it's run.
+func (as AppImageStrategy) fallback() string {
+	if fallback, err := as.Get("appimage.fallback"); err == nil && fallback == "extract-and-run" {
+		return fallback
+	}
+	return "extract"
+}
+
+// ExtractPath returns where this version is unpacked to.
+func (as AppImageStrategy) ExtractPath() (string, error) {
+	holenPath, err := as.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "appimage", fmt.Sprintf("%s--%s", as.Data.Name, as.Data.Version)), nil
+}
+
+func (as AppImageStrategy) Run(args []string) error {
+	if as.OS() != "linux" {
+		return &SkipError{"appimages only run on linux"}
+	}
+
+	localPath, err := as.Install()
+	if err != nil {
+		return err
+	}
+
+	command := localPath
+	if !as.HasFUSE() {
+		if as.fallback() == "extract-and-run" {
+			as.Debugf("no fuse available, running with --appimage-extract-and-run")
+			args = append([]string{"--appimage-extract-and-run"}, args...)
+		} else {
+			as.Debugf("no fuse available, running extracted appimage")
+			command, err = as.Extract(localPath)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	as.RecordUsage(as.Data.Name, as.Data.Version, "", "")
+	err = as.ExecCommand(command, args)
+	if err != nil {
+		return errors.Wrap(err, "can't run appimage")
+	}
+
+	return nil
+}
+
+// Install downloads and checks the AppImage, unless it's already there, and
+// returns its location.
+func (as AppImageStrategy) Install() (string, error) {
+	templated, err := as.templated()
+	if err != nil {
+		return "", err
+	}
+
+	downloadPath, err := as.DownloadPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to find download path")
+	}
+	localPath := filepath.Join(downloadPath, fmt.Sprintf("%s--%s.AppImage", as.Data.Name, as.Data.Version))
+
+	if as.FileExists(localPath) {
+		return localPath, nil
+	}
+
+	tempPath, err := as.TempPath()
+	if err != nil {
+		return "", err
+	}
+	tempdir, err := ioutil.TempDir(tempPath, "holen")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make temporary directory")
+	}
+	defer os.RemoveAll(tempdir)
+
+	appImagePath := filepath.Join(tempdir, filepath.Base(localPath))
+
+	as.Stderrf("Downloading %s...\n", templated["URL"])
+	err = as.DownloadFile(templated["URL"], appImagePath)
+	if err != nil {
+		return "", errors.Wrap(err, "can't download appimage")
+	}
+
+	err = as.ChecksumFile(appImagePath, as.ChecksumData(as.Data.Checksums, as.Data.OSArchData))
+	if err != nil {
+		if err == NoCheckSums {
+			as.Debugf("skipping checksum, no checksums provided")
+		} else {
+			return "", errors.Wrap(err, "appimage checksum failed")
+		}
+	}
+
+	err = os.Rename(appImagePath, localPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to move appimage into position")
+	}
+
+	err = as.MakeExecutable(localPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make appimage executable")
+	}
+	as.RecordDownload(localPath)
+
+	return localPath, nil
+}
+
+// Extract unpacks the AppImage into the data path, unless that's already
+// been done, and returns the path to its AppRun.
+func (as AppImageStrategy) Extract(localPath string) (string, error) {
+	extractPath, err := as.ExtractPath()
+	if err != nil {
+		return "", err
+	}
+	appRun := filepath.Join(extractPath, "AppRun")
+
+	if as.FileExists(appRun) {
+		return appRun, nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(extractPath), 0755)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make appimage directory")
+	}
+
+	// AppImages always extract into squashfs-root in the working directory,
+	// so do that somewhere private and move it into place afterwards.
+	tempdir, err := ioutil.TempDir(filepath.Dir(extractPath), "extract")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make temporary directory")
+	}
+	defer os.RemoveAll(tempdir)
+
+	as.Stderrf("Extracting %s...\n", filepath.Base(localPath))
+	err = as.RunCommandInDir(tempdir, localPath, []string{"--appimage-extract"}, filepath.Join(tempdir, "extract.log"))
+	if err != nil {
+		return "", errors.Wrap(err, "unable to extract appimage")
+	}
+
+	os.RemoveAll(extractPath)
+	err = os.Rename(filepath.Join(tempdir, "squashfs-root"), extractPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to move extracted appimage into position")
+	}
+
+	return appRun, nil
+}
+
+func (as AppImageStrategy) Inspect() error {
+	return as.showInspection(as.Inspection())
+}
+
+func (as AppImageStrategy) Inspection() (*Inspection, error) {
+	templated, err := as.templated()
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := newInspection("appimage", "AppImage Strategy", as.Data.Version)
+	inspection.Add("final_url", "final url", templated["URL"])
+	inspection.AddChecksum(as.FindChecksum(as.ChecksumData(as.Data.Checksums, as.Data.OSArchData)))
+	if as.HasFUSE() {
+		inspection.Add("run_with", "run with", "fuse")
+	} else {
+		inspection.Add("run_with", "run with", as.fallback())
+	}
+
+	return inspection, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newAppImageStrategy() (*TestUtils, *AppImageStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+	tu.MemSystem.MOS = "linux"
+
+	return tu, &AppImageStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: AppImageData{
+			Name:       "testapp",
+			Desc:       "Test AppImage",
+			Version:    "4.2",
+			URL:        "https://apps.example.com/testapp-{{.Version}}-x86_64.AppImage",
+			OSArchData: map[string]map[string]string{},
+		},
+	}
+}
+
+func TestAppImageRun(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	binDir := path.Join(tempdir, "bin")
+	os.MkdirAll(binDir, 0755)
+	ioutil.WriteFile(path.Join(binDir, "fusermount3"), []byte{}, 0755)
+
+	localPath := path.Join(tempdir, "holen/bin/testapp--4.2.AppImage")
+	extractPath := path.Join(tempdir, "holen/appimage/testapp--4.2")
+	extractCommand := fmt.Sprintf("%s --appimage-extract", localPath)
+
+	var appImageTests = []struct {
+		adjustment func(*TestUtils, *AppImageStrategy)
+		history    []string
+		downloaded bool
+	}{
+		{
+			func(tu *TestUtils, as *AppImageStrategy) {
+				tu.MemSystem.Files["/dev/fuse"] = true
+			},
+			[]string{fmt.Sprintf("%s first second", localPath)},
+			true,
+		},
+		{
+			func(tu *TestUtils, as *AppImageStrategy) {
+				tu.MemSystem.Files["/dev/fuse"] = true
+				tu.MemSystem.Files[localPath] = true
+			},
+			[]string{fmt.Sprintf("%s first second", localPath)},
+			false,
+		},
+		{
+			func(tu *TestUtils, as *AppImageStrategy) {
+				tu.MemSystem.Files[localPath] = true
+				tu.MemConfig.Set(false, "appimage.fallback", "extract-and-run")
+			},
+			[]string{fmt.Sprintf("%s --appimage-extract-and-run first second", localPath)},
+			false,
+		},
+		{
+			func(tu *TestUtils, as *AppImageStrategy) {
+				tu.MemSystem.Files[localPath] = true
+				tu.MemRunner.SetEffect(extractCommand, func() {
+					os.MkdirAll(path.Join(tu.MemRunner.HistoryDir[extractCommand], "squashfs-root"), 0755)
+				})
+			},
+			[]string{extractCommand, fmt.Sprintf("%s/AppRun first second", extractPath)},
+			false,
+		},
+		{
+			func(tu *TestUtils, as *AppImageStrategy) {
+				tu.MemSystem.Files[localPath] = true
+				tu.MemSystem.Files[path.Join(extractPath, "AppRun")] = true
+			},
+			[]string{fmt.Sprintf("%s/AppRun first second", extractPath)},
+			false,
+		},
+	}
+
+	for _, test := range appImageTests {
+		os.RemoveAll(path.Join(tempdir, "holen"))
+
+		tu, as := newAppImageStrategy()
+		tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+		tu.MemSystem.Setenv("PATH", binDir)
+		if test.adjustment != nil {
+			test.adjustment(tu, as)
+		}
+
+		assert.Nil(as.Run([]string{"first", "second"}))
+		assert.Equal(test.history, tu.MemRunner.History)
+		assert.Equal(test.downloaded, len(tu.MemDownloader.Files) > 0)
+	}
+}
+
+func TestAppImageExtracted(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	localPath := path.Join(tempdir, "holen/bin/testapp--4.2.AppImage")
+	extractCommand := fmt.Sprintf("%s --appimage-extract", localPath)
+
+	tu, as := newAppImageStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+	tu.MemSystem.Setenv("PATH", "")
+	tu.MemSystem.Files[localPath] = true
+	tu.MemRunner.SetEffect(extractCommand, func() {
+		os.MkdirAll(path.Join(tu.MemRunner.HistoryDir[extractCommand], "squashfs-root"), 0755)
+		ioutil.WriteFile(path.Join(tu.MemRunner.HistoryDir[extractCommand], "squashfs-root", "AppRun"), []byte{}, 0755)
+	})
+
+	assert.Nil(as.Run([]string{}))
+
+	_, err := os.Stat(path.Join(tempdir, "holen/appimage/testapp--4.2/AppRun"))
+	assert.Nil(err)
+
+	entries, _ := ioutil.ReadDir(path.Join(tempdir, "holen/appimage"))
+	assert.Len(entries, 1)
+}
+
+func TestAppImageNotLinux(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, as := newAppImageStrategy()
+	tu.MemSystem.MOS = "darwin"
+
+	assert.Equal(&SkipError{"appimages only run on linux"}, as.Run([]string{}))
+	assert.Empty(tu.MemDownloader.Files)
+}
+
+func TestAppImageChecksumFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, as := newAppImageStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+	as.Data.Checksums = map[string]string{"md5sum": "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"}
+
+	err := as.Run([]string{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "appimage checksum failed")
+	assert.Empty(tu.MemRunner.History)
+}
+
+func TestAppImageInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, as := newAppImageStrategy()
+	tu.MemSystem.Setenv("PATH", "")
+	as.Data.Checksums = map[string]string{"sha256sum": "abcd"}
+	assert.Nil(as.Inspect())
+
+	assert.Equal([]string{
+		"AppImage Strategy (version: 4.2):\n",
+		"  final url: https://apps.example.com/testapp-4.2-x86_64.AppImage\n",
+		"  checksum with sha256: abcd\n",
+		"  run with: extract\n",
+	}, tu.MemSystem.StdoutMessages)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+var majorVersionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+type GoData struct {
+	Name          string
+	Desc          string
+	Version       string                       `yaml:"version"`
+	Module        string                       `yaml:"module"`
+	ModuleVersion string                       `yaml:"module_version"`
+	Binary        string                       `yaml:"binary"`
+	OSArchData    map[string]map[string]string `yaml:"os_arch_map"`
+}
+
+// GoStrategy builds a utility from source with `go install`, for tools that
+// don't publish binaries for every platform.
+type GoStrategy struct {
+	*StrategyCommon
+	Data GoData
+}
+
+func (gs GoStrategy) Version() string {
+	return gs.Data.Version
+}
+
+func (gs GoStrategy) TemplateValues(values map[string]string) (map[string]string, error) {
+	return gs.CommonTemplateValues(gs.Data.Version, gs.Data.OSArchData, gs.System, values)
+}
+
+func (gs GoStrategy) templated() (map[string]string, error) {
+	moduleVersion := gs.Data.ModuleVersion
+	if len(moduleVersion) == 0 {
+		moduleVersion = "v{{.Version}}"
+	}
+
+	templated, err := gs.TemplateValues(map[string]string{
+		"Module":        gs.Data.Module,
+		"ModuleVersion": moduleVersion,
+		"Binary":        gs.Data.Binary,
+	})
+	if err != nil {
+		return templated, errors.Wrap(err, fmt.Sprintf("error in templating go version %s", gs.Data.Version))
+	}
+
+	if len(templated["Binary"]) == 0 {
+		templated["Binary"] = moduleBinary(templated["Module"])
+	}
+	if gs.OS() == "windows" {
+		templated["Binary"] += ".exe"
+	}
+
+	return templated, nil
+}
+
+// moduleBinary returns the name `go install` gives the binary for a package,
+// which is the last element of its path, ignoring any major version suffix.
+func moduleBinary(module string) string {
+	dir, base := path.Split(module)
+	if majorVersionSuffix.MatchString(base) && len(dir) > 0 {
+		base = path.Base(dir)
+	}
+	return base
+}
+
+// BinPath returns the GOBIN that this version is installed into.
+func (gs GoStrategy) BinPath() (string, error) {
+	holenPath, err := gs.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "go", gs.Data.Name, gs.Data.Version, "bin"), nil
+}
+
+func (gs GoStrategy) Run(args []string) error {
+	localPath, err := gs.Install()
+	if err != nil {
+		return err
+	}
+
+	gs.RecordUsage(gs.Data.Name, gs.Data.Version, "", "")
+	err = gs.ExecCommand(localPath, args)
+	if err != nil {
+		return errors.Wrap(err, "can't run binary")
+	}
+
+	return nil
+}
+
+// Install builds the module into its own GOBIN, unless it's already there,
+// and returns the location of the binary.
+func (gs GoStrategy) Install() (string, error) {
+	templated, err := gs.templated()
+	if err != nil {
+		return "", err
+	}
+
+	binPath, err := gs.BinPath()
+	if err != nil {
+		return "", err
+	}
+	localPath := filepath.Join(binPath, templated["Binary"])
+
+	if gs.FileExists(localPath) {
+		return localPath, nil
+	}
+
+	if !gs.CheckCommand("go", []string{"version"}) {
+		return "", &SkipError{"go not available"}
+	}
+
+	env := []string{fmt.Sprintf("GOBIN=%s", binPath)}
+	if flags, err := gs.Get("go.flags"); err == nil && len(flags) > 0 {
+		env = append(env, fmt.Sprintf("GOFLAGS=%s", flags))
+	}
+	if proxy, err := gs.Get("go.proxy"); err == nil && len(proxy) > 0 {
+		env = append(env, fmt.Sprintf("GOPROXY=%s", proxy))
+	}
+
+	pkg := fmt.Sprintf("%s@%s", templated["Module"], templated["ModuleVersion"])
+	gs.Stderrf("Installing %s...\n", pkg)
+	err = gs.RunCommandWithEnv("go", []string{"install", pkg}, env)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to install %s", pkg))
+	}
+
+	return localPath, nil
+}
+
+func (gs GoStrategy) Inspect() error {
+	return gs.showInspection(gs.Inspection())
+}
+
+func (gs GoStrategy) Inspection() (*Inspection, error) {
+	templated, err := gs.templated()
+	if err != nil {
+		return nil, err
+	}
+
+	binPath, err := gs.BinPath()
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := newInspection("go", "Go Strategy", gs.Data.Version)
+	inspection.Add("module", "module", fmt.Sprintf("%s@%s", templated["Module"], templated["ModuleVersion"]))
+	inspection.Add("binary", "binary", filepath.Join(binPath, templated["Binary"]))
+
+	return inspection, nil
+}
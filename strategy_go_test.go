@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newGoStrategy() (*TestUtils, *GoStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+	tu.MemSystem.MOS = "linux"
+	tu.MemSystem.Setenv("XDG_DATA_HOME", "/tmp")
+
+	return tu, &GoStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: GoData{
+			Name:       "testgo",
+			Desc:       "Test Go Program",
+			Version:    "1.2.0",
+			Module:     "example.com/owner/tool/cmd/testgo",
+			OSArchData: make(map[string]map[string]string),
+		},
+	}
+}
+
+func TestGoSimple(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tg := newGoStrategy()
+	assert.Nil(tg.Run([]string{"first", "second"}))
+
+	assert.Equal([]string{
+		"go install example.com/owner/tool/cmd/testgo@v1.2.0",
+		"/tmp/holen/go/testgo/1.2.0/bin/testgo first second",
+	}, tu.MemRunner.History)
+	assert.Equal([]string{"GOBIN=/tmp/holen/go/testgo/1.2.0/bin"}, tu.MemRunner.HistoryEnv["go install example.com/owner/tool/cmd/testgo@v1.2.0"])
+}
+
+func TestGoOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tg := newGoStrategy()
+	tu.MemConfig.Set(false, "go.flags", "-trimpath")
+	tu.MemConfig.Set(false, "go.proxy", "https://proxy.example.com")
+	tg.Data.Module = "example.com/owner/testgo/v2"
+	tg.Data.ModuleVersion = "{{.Version}}-rc1"
+	assert.Nil(tg.Run([]string{}))
+
+	assert.Equal([]string{
+		"go install example.com/owner/testgo/v2@1.2.0-rc1",
+		"/tmp/holen/go/testgo/1.2.0/bin/testgo",
+	}, tu.MemRunner.History)
+	assert.Equal([]string{
+		"GOBIN=/tmp/holen/go/testgo/1.2.0/bin",
+		"GOFLAGS=-trimpath",
+		"GOPROXY=https://proxy.example.com",
+	}, tu.MemRunner.HistoryEnv["go install example.com/owner/testgo/v2@1.2.0-rc1"])
+}
+
+func TestGoCached(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tg := newGoStrategy()
+	tg.Data.Binary = "othername"
+	tu.MemSystem.Files[path.Join("/tmp/holen/go/testgo/1.2.0/bin", "othername")] = true
+	assert.Nil(tg.Run([]string{}))
+
+	assert.Equal([]string{"/tmp/holen/go/testgo/1.2.0/bin/othername"}, tu.MemRunner.History)
+}
+
+func TestGoNotInstalled(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tg := newGoStrategy()
+	tu.MemRunner.FailCheck("go version")
+
+	err := tg.Run([]string{})
+	assert.Equal(&SkipError{"go not available"}, err)
+	assert.Empty(tu.MemRunner.History)
+}
+
+func TestGoInstallFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tg := newGoStrategy()
+	tu.MemRunner.FailCommand("go install example.com/owner/tool/cmd/testgo@v1.2.0", fmt.Errorf("build failed"))
+
+	err := tg.Run([]string{})
+	assert.NotNil(err)
+	assert.Len(tu.MemRunner.History, 1)
+}
+
+func TestGoInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tg := newGoStrategy()
+	assert.Nil(tg.Inspect())
+
+	assert.Equal([]string{
+		"Go Strategy (version: 1.2.0):\n",
+		"  module: example.com/owner/tool/cmd/testgo@v1.2.0\n",
+		"  binary: /tmp/holen/go/testgo/1.2.0/bin/testgo\n",
+	}, tu.MemSystem.StdoutMessages)
+}
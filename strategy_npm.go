@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// npmInstalledMarker is written into the prefix once the package has been
+// installed and verified.
+const npmInstalledMarker = ".holen-installed"
+
+type NpmData struct {
+	Name           string
+	Desc           string
+	Version        string                       `yaml:"version"`
+	Package        string                       `yaml:"package"`
+	PackageVersion string                       `yaml:"package_version"`
+	Bin            string                       `yaml:"bin"`
+	Integrity      string                       `yaml:"integrity"`
+	OSArchData     map[string]map[string]string `yaml:"os_arch_map"`
+}
+
+// NpmStrategy installs a package from npm into its own prefix and runs one
+// of the commands it provides.
+type NpmStrategy struct {
+	*StrategyCommon
+	Data NpmData
+}
+
+type npmLockfile struct {
+	Packages map[string]struct {
+		Integrity string `json:"integrity"`
+	} `json:"packages"`
+	Dependencies map[string]struct {
+		Integrity string `json:"integrity"`
+	} `json:"dependencies"`
+}
+
+func (ns NpmStrategy) Version() string {
+	return ns.Data.Version
+}
+
+func (ns NpmStrategy) TemplateValues(values map[string]string) (map[string]string, error) {
+	return ns.CommonTemplateValues(ns.Data.Version, ns.Data.OSArchData, ns.System, values)
+}
+
+func (ns NpmStrategy) templated() (map[string]string, error) {
+	values := map[string]string{
+		"Package":        ns.Data.Package,
+		"PackageVersion": ns.Data.PackageVersion,
+		"Bin":            ns.Data.Bin,
+	}
+	if len(values["Package"]) == 0 {
+		values["Package"] = ns.Data.Name
+	}
+	if len(values["PackageVersion"]) == 0 {
+		values["PackageVersion"] = "{{.Version}}"
+	}
+
+	templated, err := ns.TemplateValues(values)
+	if err != nil {
+		return templated, errors.Wrap(err, fmt.Sprintf("error in templating npm version %s", ns.Data.Version))
+	}
+
+	// scoped packages like @aws-cdk/cli usually provide a command named
+	// after the last part
+	if len(templated["Bin"]) == 0 {
+		templated["Bin"] = path.Base(templated["Package"])
+	}
+
+	return templated, nil
+}
+
+// PrefixPath returns the npm prefix that this version is installed into.
+func (ns NpmStrategy) PrefixPath() (string, error) {
+	holenPath, err := ns.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "npm", ns.Data.Name, ns.Data.Version), nil
+}
+
+func (ns NpmStrategy) binPath(prefixPath, name string) string {
+	if ns.OS() == "windows" {
+		name = fmt.Sprintf("%s.cmd", name)
+	}
+	return filepath.Join(prefixPath, "node_modules", ".bin", name)
+}
+
+func (ns NpmStrategy) Run(args []string) error {
+	localPath, err := ns.Install()
+	if err != nil {
+		return err
+	}
+
+	ns.RecordUsage(ns.Data.Name, ns.Data.Version, "", "")
+	err = ns.ExecCommand(localPath, args)
+	if err != nil {
+		return errors.Wrap(err, "can't run command")
+	}
+
+	return nil
+}
+
+// Install installs the package into its prefix, unless that's already been
+// done, and returns the location of the command.
+func (ns NpmStrategy) Install() (string, error) {
+	templated, err := ns.templated()
+	if err != nil {
+		return "", err
+	}
+
+	prefixPath, err := ns.PrefixPath()
+	if err != nil {
+		return "", err
+	}
+	localPath := ns.binPath(prefixPath, templated["Bin"])
+	markerPath := filepath.Join(prefixPath, npmInstalledMarker)
+
+	if ns.FileExists(markerPath) {
+		return localPath, nil
+	}
+
+	if !ns.CheckCommand("npm", []string{"--version"}) {
+		return "", &SkipError{"npm not available"}
+	}
+
+	// start over if a previous install didn't finish
+	err = os.RemoveAll(prefixPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to remove partial install")
+	}
+	os.MkdirAll(prefixPath, 0755)
+
+	pkg := fmt.Sprintf("%s@%s", templated["Package"], templated["PackageVersion"])
+	ns.Stderrf("Installing %s...\n", pkg)
+
+	err = ns.RunCommand("npm", []string{"install", "--prefix", prefixPath, "--no-audit", "--no-fund", "--save-exact", pkg})
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to install %s", pkg))
+	}
+
+	if len(ns.Data.Integrity) > 0 {
+		err = ns.verifyIntegrity(prefixPath, templated["Package"])
+		if err != nil {
+			os.RemoveAll(prefixPath)
+			return "", errors.Wrap(err, "package integrity check failed")
+		}
+	}
+
+	err = ioutil.WriteFile(markerPath, []byte(pkg+"\n"), 0644)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to mark package as installed")
+	}
+
+	return localPath, nil
+}
+
+// verifyIntegrity compares the integrity that npm recorded in the lockfile
+// for the installed package with the one in the manifest.
+func (ns NpmStrategy) verifyIntegrity(prefixPath, pkg string) error {
+	data, err := ioutil.ReadFile(filepath.Join(prefixPath, "package-lock.json"))
+	if err != nil {
+		return errors.Wrap(err, "unable to read package-lock.json")
+	}
+
+	var lockfile npmLockfile
+	err = json.Unmarshal(data, &lockfile)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse package-lock.json")
+	}
+
+	integrity := lockfile.Packages[fmt.Sprintf("node_modules/%s", pkg)].Integrity
+	if len(integrity) == 0 {
+		// lockfile version 1
+		integrity = lockfile.Dependencies[pkg].Integrity
+	}
+	if len(integrity) == 0 {
+		return fmt.Errorf("no integrity found for %s in package-lock.json", pkg)
+	}
+
+	if integrity != ns.Data.Integrity {
+		return fmt.Errorf("expected %s and got %s", ns.Data.Integrity, integrity)
+	}
+
+	return nil
+}
+
+func (ns NpmStrategy) Inspect() error {
+	return ns.showInspection(ns.Inspection())
+}
+
+func (ns NpmStrategy) Inspection() (*Inspection, error) {
+	templated, err := ns.templated()
+	if err != nil {
+		return nil, err
+	}
+
+	prefixPath, err := ns.PrefixPath()
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := newInspection("npm", "Npm Strategy", ns.Data.Version)
+	inspection.Add("package", "package", fmt.Sprintf("%s@%s", templated["Package"], templated["PackageVersion"]))
+	if len(ns.Data.Integrity) > 0 {
+		inspection.Add("integrity", "integrity", ns.Data.Integrity)
+	}
+	inspection.Add("command", "command", ns.binPath(prefixPath, templated["Bin"]))
+
+	return inspection, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newNpmStrategy() (*TestUtils, *NpmStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+	tu.MemSystem.MOS = "linux"
+
+	return tu, &NpmStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: NpmData{
+			Name:       "testnpm",
+			Desc:       "Test Npm Program",
+			Version:    "3.0.1",
+			OSArchData: make(map[string]map[string]string),
+		},
+	}
+}
+
+func TestNpmInstall(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	prefix := path.Join(tempdir, "holen/npm/testnpm/3.0.1")
+
+	var installTests = []struct {
+		adjustment func(*NpmStrategy)
+		lockfile   string
+		history    []string
+		err        string
+	}{
+		{
+			nil,
+			"",
+			[]string{
+				fmt.Sprintf("npm install --prefix %s --no-audit --no-fund --save-exact testnpm@3.0.1", prefix),
+				fmt.Sprintf("%s/node_modules/.bin/testnpm first second", prefix),
+			},
+			"",
+		},
+		{
+			func(tn *NpmStrategy) {
+				tn.Data.Package = "@scope/tool"
+				tn.Data.PackageVersion = "{{.Version}}-beta"
+				tn.Data.Integrity = "sha512-good"
+			},
+			`{"lockfileVersion":3,"packages":{"node_modules/@scope/tool":{"integrity":"sha512-good"}}}`,
+			[]string{
+				fmt.Sprintf("npm install --prefix %s --no-audit --no-fund --save-exact @scope/tool@3.0.1-beta", prefix),
+				fmt.Sprintf("%s/node_modules/.bin/tool first second", prefix),
+			},
+			"",
+		},
+		{
+			func(tn *NpmStrategy) {
+				tn.Data.Bin = "tn"
+				tn.Data.Integrity = "sha512-good"
+			},
+			`{"lockfileVersion":1,"dependencies":{"testnpm":{"integrity":"sha512-good"}}}`,
+			[]string{
+				fmt.Sprintf("npm install --prefix %s --no-audit --no-fund --save-exact testnpm@3.0.1", prefix),
+				fmt.Sprintf("%s/node_modules/.bin/tn first second", prefix),
+			},
+			"",
+		},
+		{
+			func(tn *NpmStrategy) {
+				tn.Data.Integrity = "sha512-good"
+			},
+			`{"lockfileVersion":3,"packages":{"node_modules/testnpm":{"integrity":"sha512-evil"}}}`,
+			[]string{
+				fmt.Sprintf("npm install --prefix %s --no-audit --no-fund --save-exact testnpm@3.0.1", prefix),
+			},
+			"expected sha512-good and got sha512-evil",
+		},
+		{
+			func(tn *NpmStrategy) {
+				tn.Data.Integrity = "sha512-good"
+			},
+			`{"lockfileVersion":3,"packages":{}}`,
+			[]string{
+				fmt.Sprintf("npm install --prefix %s --no-audit --no-fund --save-exact testnpm@3.0.1", prefix),
+			},
+			"no integrity found for testnpm",
+		},
+	}
+
+	for _, test := range installTests {
+		tu, tn := newNpmStrategy()
+		tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+		lw := &PackageLockWriter{prefix, test.lockfile}
+		if test.adjustment != nil {
+			test.adjustment(tn)
+		}
+		tu.MemRunner.SetEffect(test.history[0], lw.Write)
+
+		err := tn.Run([]string{"first", "second"})
+		assert.Equal(test.history, tu.MemRunner.History)
+
+		_, statErr := os.Stat(path.Join(prefix, npmInstalledMarker))
+		if len(test.err) > 0 {
+			assert.NotNil(err)
+			if err != nil {
+				assert.Contains(err.Error(), test.err)
+			}
+			assert.True(os.IsNotExist(statErr))
+		} else {
+			assert.Nil(err)
+			assert.Nil(statErr)
+		}
+	}
+}
+
+// PackageLockWriter writes the lockfile npm would have left behind.
+type PackageLockWriter struct {
+	prefix   string
+	contents string
+}
+
+func (lw *PackageLockWriter) Write() {
+	if len(lw.contents) > 0 {
+		ioutil.WriteFile(path.Join(lw.prefix, "package-lock.json"), []byte(lw.contents), 0644)
+	}
+}
+
+func TestNpmInstalled(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tn := newNpmStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", "/tmp")
+	tu.MemSystem.Files["/tmp/holen/npm/testnpm/3.0.1/"+npmInstalledMarker] = true
+
+	assert.Nil(tn.Run([]string{}))
+	assert.Equal([]string{"/tmp/holen/npm/testnpm/3.0.1/node_modules/.bin/testnpm"}, tu.MemRunner.History)
+}
+
+func TestNpmNotInstalled(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tn := newNpmStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", "/tmp")
+	tu.MemRunner.FailCheck("npm --version")
+
+	assert.Equal(&SkipError{"npm not available"}, tn.Run([]string{}))
+	assert.Empty(tu.MemRunner.History)
+}
+
+func TestNpmInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tn := newNpmStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", "/tmp")
+	tn.Data.Integrity = "sha512-good"
+	assert.Nil(tn.Inspect())
+
+	assert.Equal([]string{
+		"Npm Strategy (version: 3.0.1):\n",
+		"  package: testnpm@3.0.1\n",
+		"  integrity: sha512-good\n",
+		"  command: /tmp/holen/npm/testnpm/3.0.1/node_modules/.bin/testnpm\n",
+	}, tu.MemSystem.StdoutMessages)
+}
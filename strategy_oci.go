@@ -0,0 +1,332 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxLinkHops limits how many symlinks are followed when looking for a file
+// inside of an image.
+const maxLinkHops = 10
+
+type OCIData struct {
+	Name       string
+	Desc       string
+	Version    string                       `yaml:"version"`
+	Image      string                       `yaml:"image"`
+	Path       string                       `yaml:"path"`
+	OSArchData map[string]map[string]string `yaml:"os_arch_map"`
+}
+
+// OCIStrategy extracts a single file from a container image, talking to the
+// registry directly so that Docker doesn't need to be installed.
+type OCIStrategy struct {
+	*StrategyCommon
+	Data OCIData
+}
+
+func (oc OCIStrategy) Version() string {
+	return oc.Data.Version
+}
+
+func (oc OCIStrategy) TemplateValues(values map[string]string) (map[string]string, error) {
+	return oc.CommonTemplateValues(oc.Data.Version, oc.Data.OSArchData, oc.System, values)
+}
+
+func (oc OCIStrategy) registryClient(image string) *RegistryClient {
+	var insecure []string
+	if configInsecure, err := oc.Get("oci.insecure_registries"); err == nil && len(configInsecure) > 0 {
+		insecure = strings.Split(configInsecure, ",")
+	}
+
+	client := NewRegistryClient(oc.Logger, ParseImageReference(image), insecure)
+	client.MaxSize = oc.MaxArchiveSize()
+
+	return client
+}
+
+func (oc OCIStrategy) Run(args []string) error {
+	localPath, err := oc.Install()
+	if err != nil {
+		return err
+	}
+
+	oc.RecordUsage(oc.Data.Name, oc.Data.Version, "", "")
+	err = oc.ExecCommand(localPath, args)
+	if err != nil {
+		return errors.Wrap(err, "can't run binary")
+	}
+
+	return nil
+}
+
+// Install extracts the binary from the image into the download path, unless
+// it's already there, and returns its location.
+func (oc OCIStrategy) Install() (string, error) {
+	templated, err := oc.TemplateValues(map[string]string{
+		"Image": oc.Data.Image,
+		"Path":  oc.Data.Path,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	downloadPath, err := oc.DownloadPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to find download path")
+	}
+	binName := fmt.Sprintf("%s--%s", oc.Data.Name, oc.Data.Version)
+	localPath := filepath.Join(downloadPath, binName)
+
+	if oc.FileExists(localPath) {
+		return localPath, nil
+	}
+
+	client := oc.registryClient(templated["Image"])
+
+	manifest, err := client.ImageManifest(oc.OS(), oc.Arch())
+	if err != nil {
+		return "", err
+	}
+
+	if len(manifest.Config.Digest) > 0 {
+		platform, err := client.ImagePlatform(manifest)
+		if err != nil {
+			return "", err
+		}
+
+		if len(platform.OS) > 0 && (platform.OS != oc.OS() || platform.Architecture != oc.Arch()) {
+			oc.Debugf("skipping, image is for %s/%s", platform.OS, platform.Architecture)
+			return "", &SkipError{fmt.Sprintf("image %s not available for %s/%s", client.Ref, oc.OS(), oc.Arch())}
+		}
+	}
+
+	tempPath, err := oc.TempPath()
+	if err != nil {
+		return "", err
+	}
+	tempdir, err := ioutil.TempDir(tempPath, "holen")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make temporary directory")
+	}
+	defer os.RemoveAll(tempdir)
+
+	binPath := filepath.Join(tempdir, binName)
+
+	oc.Stderrf("Extracting %s from %s...\n", templated["Path"], client.Ref)
+	err = oc.extractFile(client, manifest.Layers, templated["Path"], tempdir, binPath)
+	if err != nil {
+		return "", err
+	}
+
+	err = oc.ChecksumFile(binPath, oc.Data.OSArchData)
+	if err != nil {
+		if err == NoCheckSums {
+			oc.Debugf("skipping checksum, no checksums provided")
+		} else {
+			return "", errors.Wrap(err, "binary checksum failed")
+		}
+	}
+
+	err = os.Rename(binPath, localPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to move binary into position")
+	}
+
+	err = oc.MakeExecutable(localPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make binary executable")
+	}
+	oc.RecordDownload(localPath)
+
+	return localPath, nil
+}
+
+// extractFile searches the layers from the top down for target, following
+// symlinks and honoring whiteouts, and writes its contents to outPath.
+// Layers are only downloaded when the search reaches them.
+func (oc OCIStrategy) extractFile(client *RegistryClient, layers []ociDescriptor, target, tempdir, outPath string) error {
+	downloaded := make(map[string]string)
+	target = cleanLayerPath(target)
+
+	for hops := 0; hops < maxLinkHops; hops++ {
+		var result layerEntry
+
+		for i := len(layers) - 1; i >= 0; i-- {
+			layer := layers[i]
+
+			layerPath, ok := downloaded[layer.Digest]
+			if !ok {
+				layerPath = filepath.Join(tempdir, strings.Replace(layer.Digest, ":", "-", 1))
+				oc.Debugf("downloading layer %s", layer.Digest)
+				err := client.DownloadBlob(layer, layerPath)
+				if err != nil {
+					return errors.Wrap(err, fmt.Sprintf("unable to download layer %s", layer.Digest))
+				}
+				downloaded[layer.Digest] = layerPath
+			}
+
+			var err error
+			result, err = findInLayer(layerPath, target, outPath, client.maxSize())
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("unable to read layer %s", layer.Digest))
+			}
+
+			if result.status != entryMissing {
+				break
+			}
+		}
+
+		switch result.status {
+		case entryFound:
+			return nil
+		case entryLink:
+			oc.Debugf("following link from %s to %s", target, result.linkTarget)
+			target = result.linkTarget
+		default:
+			return fmt.Errorf("%s not found in image %s", target, client.Ref)
+		}
+	}
+
+	return fmt.Errorf("too many links when looking for %s in image %s", target, client.Ref)
+}
+
+func (oc OCIStrategy) Inspect() error {
+	return oc.showInspection(oc.Inspection())
+}
+
+func (oc OCIStrategy) Inspection() (*Inspection, error) {
+	templated, err := oc.TemplateValues(map[string]string{
+		"Image": oc.Data.Image,
+		"Path":  oc.Data.Path,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("error in templating oci version %s", oc.Data.Version))
+	}
+
+	inspection := newInspection("oci", "OCI Strategy", oc.Data.Version)
+	inspection.Add("final_image", "final image", fmt.Sprint(ParseImageReference(templated["Image"])))
+	inspection.Add("file", "file", templated["Path"])
+	inspection.AddChecksum(oc.FindChecksum(oc.Data.OSArchData))
+
+	return inspection, nil
+}
+
+const (
+	entryMissing = iota
+	entryFound
+	entryDeleted
+	entryLink
+)
+
+type layerEntry struct {
+	status     int
+	linkTarget string
+}
+
+func cleanLayerPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// findInLayer looks through one layer tarball for target.  A regular file is
+// written to outPath, as long as it's no larger than maxSize, a link reports
+// where it points and a whiteout reports that the file was deleted.
+func findInLayer(layerPath, target, outPath string, maxSize int64) (layerEntry, error) {
+	result := layerEntry{status: entryMissing}
+
+	file, err := os.Open(layerPath)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+
+	buffered := bufio.NewReader(file)
+	var reader io.Reader = buffered
+	magic, _ := buffered.Peek(4)
+	if bytes.HasPrefix(magic, []byte{0x1f, 0x8b}) {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return result, err
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	} else if bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		return result, fmt.Errorf("zstd compressed layers are not supported")
+	}
+
+	deleted := false
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return result, err
+		}
+
+		name := cleanLayerPath(header.Name)
+		dir, base := path.Split(name)
+		dir = strings.TrimSuffix(dir, "/")
+
+		if base == ".wh..wh..opq" {
+			if strings.HasPrefix(target, dir+"/") {
+				deleted = true
+			}
+			continue
+		} else if strings.HasPrefix(base, ".wh.") {
+			removed := path.Join(dir, strings.TrimPrefix(base, ".wh."))
+			if target == removed || strings.HasPrefix(target, removed+"/") {
+				deleted = true
+			}
+			continue
+		}
+
+		if name != target {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			if header.Size > maxSize {
+				return result, fmt.Errorf("%s is %d bytes, more than the %d allowed", target, header.Size, maxSize)
+			}
+			out, err := os.Create(outPath)
+			if err != nil {
+				return result, err
+			}
+			_, err = io.Copy(out, tarReader)
+			out.Close()
+			if err != nil {
+				return result, err
+			}
+			return layerEntry{status: entryFound}, nil
+		case tar.TypeSymlink:
+			linkTarget := header.Linkname
+			if !path.IsAbs(linkTarget) {
+				linkTarget = path.Join(path.Dir(name), linkTarget)
+			}
+			return layerEntry{status: entryLink, linkTarget: cleanLayerPath(linkTarget)}, nil
+		case tar.TypeLink:
+			return layerEntry{status: entryLink, linkTarget: cleanLayerPath(header.Linkname)}, nil
+		default:
+			return result, fmt.Errorf("%s is not a regular file", target)
+		}
+	}
+
+	if deleted {
+		result.status = entryDeleted
+	}
+
+	return result, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLayerEntry struct {
+	name     string
+	linkname string
+	contents string
+}
+
+func makeTestLayer(entries []testLayerEntry) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0755}
+		if len(entry.linkname) > 0 {
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.linkname
+		} else {
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(entry.contents))
+		}
+		tarWriter.WriteHeader(header)
+		tarWriter.Write([]byte(entry.contents))
+	}
+
+	tarWriter.Close()
+	gzipWriter.Close()
+
+	return buf.Bytes()
+}
+
+func testDigest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+// newTestRegistry serves a single multi-platform image, asking for a bearer
+// token on first contact like most public registries do.
+func newTestRegistry(arch string, corruptLayer bool) *httptest.Server {
+	blobs := make(map[string][]byte)
+	manifests := make(map[string][]byte)
+
+	addBlob := func(data []byte) ociDescriptor {
+		digest := testDigest(data)
+		blobs[digest] = data
+		return ociDescriptor{Digest: digest, Size: int64(len(data))}
+	}
+
+	config := addBlob([]byte(fmt.Sprintf(`{"os":"linux","architecture":"%s"}`, arch)))
+	config.MediaType = "application/vnd.oci.image.config.v1+json"
+
+	bottom := addBlob(makeTestLayer([]testLayerEntry{
+		{name: "usr/bin/tool", contents: "old content"},
+		{name: "usr/bin/gone", contents: "gone content"},
+	}))
+	top := addBlob(makeTestLayer([]testLayerEntry{
+		{name: "usr/bin/tool", contents: "new content"},
+		{name: "usr/bin/.wh.gone"},
+		{name: "bin/tool", linkname: "../usr/bin/tool"},
+	}))
+	if corruptLayer {
+		blobs[top.Digest] = []byte("corrupted")
+	}
+
+	imageManifest, _ := json.Marshal(ociManifest{
+		MediaType: mediaTypeOCIManifest,
+		Config:    config,
+		Layers:    []ociDescriptor{bottom, top},
+	})
+	imageDigest := testDigest(imageManifest)
+	manifests[imageDigest] = imageManifest
+
+	index, _ := json.Marshal(ociManifest{
+		MediaType: mediaTypeOCIIndex,
+		Manifests: []ociDescriptor{
+			{
+				MediaType: mediaTypeOCIManifest,
+				Digest:    imageDigest,
+				Size:      int64(len(imageManifest)),
+				Platform:  &ociPlatform{OS: "linux", Architecture: arch},
+			},
+		},
+	})
+	manifests["1.0"] = index
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			fmt.Fprint(w, `{"token":"testtoken"}`)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer testtoken" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/v2/tools/tool/manifests/") {
+			if data, ok := manifests[path.Base(r.URL.Path)]; ok {
+				w.Write(data)
+				return
+			}
+		} else if strings.HasPrefix(r.URL.Path, "/v2/tools/tool/blobs/") {
+			if data, ok := blobs[path.Base(r.URL.Path)]; ok {
+				w.Write(data)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	return server
+}
+
+func newOCIStrategy(server *httptest.Server) (*TestUtils, *OCIStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     &MemConfig{},
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+	tu.MemSystem.MOS = "linux"
+	tu.MemSystem.MArch = "amd64"
+
+	return tu, &OCIStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: OCIData{
+			Name:       "testoci",
+			Desc:       "Test OCI Program",
+			Version:    "1.0",
+			Image:      strings.TrimPrefix(server.URL, "http://") + "/tools/tool:{{.Version}}",
+			Path:       "/usr/bin/tool",
+			OSArchData: make(map[string]map[string]string),
+		},
+	}
+}
+
+func TestOCIExtract(t *testing.T) {
+	assert := assert.New(t)
+
+	var extractTests = []struct {
+		arch      string
+		corrupt   bool
+		filePath  string
+		contents  string
+		errString string
+		skip      bool
+	}{
+		{"amd64", false, "/usr/bin/tool", "new content", "", false},
+		{"amd64", false, "bin/tool", "new content", "", false},
+		{"amd64", false, "/usr/bin/gone", "", "usr/bin/gone not found", false},
+		{"amd64", true, "/usr/bin/tool", "", "unable to download layer", false},
+		{"arm64", false, "/usr/bin/tool", "", "not available for linux/amd64", true},
+	}
+
+	for _, test := range extractTests {
+		func() {
+			server := newTestRegistry(test.arch, test.corrupt)
+			defer server.Close()
+
+			tempdir, _ := ioutil.TempDir("", "holen")
+			defer os.RemoveAll(tempdir)
+
+			tu, to := newOCIStrategy(server)
+			tu.MemSystem.Setenv("HOME", tempdir)
+			to.Data.Path = test.filePath
+
+			err := to.Run([]string{"first", "second"})
+
+			if len(test.errString) > 0 {
+				assert.NotNil(err)
+				if err != nil {
+					assert.Contains(err.Error(), test.errString)
+				}
+				_, isSkip := err.(*SkipError)
+				assert.Equal(test.skip, isSkip)
+				assert.Empty(tu.MemRunner.History)
+				return
+			}
+
+			assert.Nil(err)
+			localPath := path.Join(tempdir, ".local/share/holen/bin/testoci--1.0")
+			contents, _ := ioutil.ReadFile(localPath)
+			assert.Equal(test.contents, string(contents))
+			assert.Equal([]string{fmt.Sprintf("%s first second", localPath)}, tu.MemRunner.History)
+		}()
+	}
+}
+
+func TestOCIChecksum(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newTestRegistry("amd64", false)
+	defer server.Close()
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, to := newOCIStrategy(server)
+	tu.MemSystem.Setenv("HOME", tempdir)
+	to.Data.OSArchData["linux_amd64"] = map[string]string{"md5sum": "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"}
+
+	err := to.Run([]string{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "binary checksum failed")
+	assert.Empty(tu.MemRunner.History)
+}
+
+func TestOCIMaxSize(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newTestRegistry("amd64", false)
+	defer server.Close()
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	// layers larger than archive.max_size aren't downloaded
+	tu, to := newOCIStrategy(server)
+	tu.MemSystem.Setenv("HOME", tempdir)
+	tu.MemConfig.UserConfig = map[string]string{"archive.max_size": "10"}
+
+	err := to.Run([]string{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "more than the 10 allowed")
+	assert.Empty(tu.MemRunner.History)
+
+	// nor are those whose size in the manifest is wrong
+	client := NewRegistryClient(tu.MemLogger, ParseImageReference(strings.TrimPrefix(server.URL, "http://")+"/tools/tool:1.0"), nil)
+	manifest, err := client.ImageManifest("linux", "amd64")
+	assert.Nil(err)
+
+	layer := manifest.Layers[1]
+	layer.Size = 0
+	layerPath := path.Join(tempdir, "layer")
+	client.MaxSize = 10
+	err = client.DownloadBlob(layer, layerPath)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "is more than the 10 bytes allowed")
+	_, err = os.Stat(layerPath)
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(layerPath + ".partial")
+	assert.True(os.IsNotExist(err))
+
+	// and files in a layer are held to it too
+	client.MaxSize = 0
+	assert.Nil(client.DownloadBlob(layer, layerPath))
+	_, err = findInLayer(layerPath, "usr/bin/tool", path.Join(tempdir, "tool"), 5)
+	assert.EqualError(err, "usr/bin/tool is 11 bytes, more than the 5 allowed")
+}
+
+func TestOCIInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	server := newTestRegistry("amd64", false)
+	defer server.Close()
+
+	tu, to := newOCIStrategy(server)
+	assert.Nil(to.Inspect())
+	assert.Contains(tu.MemSystem.StdoutMessages[0], "OCI Strategy (version: 1.0)")
+	assert.Contains(tu.MemSystem.StdoutMessages[1], "/tools/tool:1.0")
+	assert.Equal("  file: /usr/bin/tool\n", tu.MemSystem.StdoutMessages[2])
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// pluginProtocol is bumped whenever the request or response format changes
+// in a way that plugins need to know about.
+const pluginProtocol = 1
+
+type PluginData struct {
+	Name       string
+	Desc       string
+	Version    string                       `yaml:"version"`
+	Plugin     string                       `yaml:"plugin"`
+	Settings   map[string]interface{}       `yaml:"settings"`
+	OSArchData map[string]map[string]string `yaml:"os_arch_map"`
+}
+
+// PluginStrategy hands the strategy data to an external holen-strategy-<name>
+// executable, which decides what to run.  This lets strategies be added
+// without changing holen.
+type PluginStrategy struct {
+	*StrategyCommon
+	Data PluginData
+}
+
+// PluginRequest is written to the plugin as JSON on stdin.
+type PluginRequest struct {
+	Protocol int                    `json:"protocol"`
+	Name     string                 `json:"name"`
+	Version  string                 `json:"version"`
+	OS       string                 `json:"os"`
+	Arch     string                 `json:"arch"`
+	Args     []string               `json:"args"`
+	Data     map[string]interface{} `json:"data"`
+}
+
+// PluginResponse is read back from the plugin's stdout.  Action is one of
+// "exec", "skip" or "error".
+type PluginResponse struct {
+	Action  string   `json:"action"`
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Env     []string `json:"env"`
+	Reason  string   `json:"reason"`
+	Error   string   `json:"error"`
+}
+
+func (ps PluginStrategy) Version() string {
+	return ps.Data.Version
+}
+
+func (ps PluginStrategy) executable() string {
+	return fmt.Sprintf("holen-strategy-%s", ps.Data.Plugin)
+}
+
+// FindPlugin returns the full path to the plugin executable.
+func (ps PluginStrategy) FindPlugin() (string, error) {
+	found := lookPath(ps.Getenv("PATH"), ps.executable())
+	if len(found) == 0 {
+		return "", &SkipError{fmt.Sprintf("plugin %s not available", ps.executable())}
+	}
+
+	return found[0], nil
+}
+
+// templateSettings templates every string in the settings, including those
+// nested in lists and maps.
+func (ps PluginStrategy) templateSettings(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		templated, err := ps.CommonTemplateValues(ps.Data.Version, ps.Data.OSArchData, ps.System, map[string]string{"value": typed})
+		if err != nil {
+			return nil, err
+		}
+		return templated["value"], nil
+	case []interface{}:
+		list := make([]interface{}, len(typed))
+		for i, item := range typed {
+			templated, err := ps.templateSettings(item)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = templated
+		}
+		return list, nil
+	case map[string]interface{}:
+		settings := make(map[string]interface{})
+		for key, item := range typed {
+			templated, err := ps.templateSettings(item)
+			if err != nil {
+				return nil, err
+			}
+			settings[key] = templated
+		}
+		return settings, nil
+	}
+
+	return value, nil
+}
+
+// Request builds what's sent to the plugin.
+func (ps PluginStrategy) Request(args []string) (*PluginRequest, error) {
+	settings, err := ps.templateSettings(ps.Data.Settings)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("error in templating %s version %s", ps.Data.Plugin, ps.Data.Version))
+	}
+
+	return &PluginRequest{
+		Protocol: pluginProtocol,
+		Name:     ps.Data.Name,
+		Version:  ps.Data.Version,
+		OS:       ps.OS(),
+		Arch:     ps.Arch(),
+		Args:     args,
+		Data:     settings.(map[string]interface{}),
+	}, nil
+}
+
+func (ps PluginStrategy) Run(args []string) error {
+	plugin, err := ps.FindPlugin()
+	if err != nil {
+		return err
+	}
+
+	request, err := ps.Request(args)
+	if err != nil {
+		return err
+	}
+
+	input, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "unable to encode plugin request")
+	}
+
+	output, err := ps.CommandOutputWithInput(plugin, []string{}, string(input))
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("plugin %s failed", ps.executable()))
+	}
+
+	var response PluginResponse
+	err = json.Unmarshal([]byte(output), &response)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("unable to decode response from %s", ps.executable()))
+	}
+
+	switch response.Action {
+	case "exec":
+		if len(response.Command) == 0 {
+			return fmt.Errorf("plugin %s did not give a command to run", ps.executable())
+		}
+
+		ps.RecordUsage(ps.Data.Name, ps.Data.Version, "", "")
+		err = ps.ExecCommandWithEnv(response.Command, response.Args, response.Env)
+		if err != nil {
+			return errors.Wrap(err, "can't run plugin command")
+		}
+		return nil
+	case "skip":
+		return &SkipError{response.Reason}
+	case "error":
+		return fmt.Errorf("plugin %s: %s", ps.executable(), response.Error)
+	}
+
+	return fmt.Errorf("unknown action '%s' from plugin %s", response.Action, ps.executable())
+}
+
+func (ps PluginStrategy) Inspect() error {
+	return ps.showInspection(ps.Inspection())
+}
+
+func (ps PluginStrategy) Inspection() (*Inspection, error) {
+	inspection := newInspection("plugin", "Plugin Strategy", ps.Data.Version)
+
+	plugin, err := ps.FindPlugin()
+	if err != nil {
+		inspection.add("plugin", map[string]string{"executable": ps.executable(), "path": ""},
+			fmt.Sprintf("  plugin: %s (not found)\n", ps.executable()))
+	} else {
+		inspection.add("plugin", map[string]string{"executable": ps.executable(), "path": plugin},
+			fmt.Sprintf("  plugin: %s (%s)\n", ps.executable(), plugin))
+	}
+
+	settings, err := ps.templateSettings(ps.Data.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{}
+	for key := range ps.Data.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := []string{}
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("  %s: %v\n", key, settings.(map[string]interface{})[key]))
+	}
+	inspection.add("settings", settings, lines...)
+
+	return inspection, nil
+}
+
+// jsonValue converts data read from a manifest into something that can be
+// encoded as JSON, since yaml maps are keyed by interface{}.
+func jsonValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{})
+		for key, item := range typed {
+			converted[fmt.Sprintf("%v", key)] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		list := make([]interface{}, len(typed))
+		for i, item := range typed {
+			list[i] = jsonValue(item)
+		}
+		return list
+	}
+
+	return value
+}
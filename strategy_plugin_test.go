@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPluginStrategy() (*TestUtils, *PluginStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+
+	return tu, &PluginStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: PluginData{
+			Name:    "testplugged",
+			Desc:    "Test Plugged Program",
+			Version: "2.1",
+			Plugin:  "nix",
+			Settings: map[string]interface{}{
+				"attribute": "nixpkgs.testplugged_{{.Version}}",
+				"channels":  []interface{}{"nixos-{{.Version}}"},
+				"flakes":    true,
+			},
+			OSArchData: map[string]map[string]string{},
+		},
+	}
+}
+
+func TestPluginRun(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	plugin := path.Join(tempdir, "holen-strategy-nix")
+	ioutil.WriteFile(plugin, []byte{}, 0755)
+
+	var pluginTests = []struct {
+		response string
+		err      error
+		history  []string
+		env      []string
+	}{
+		{
+			`{"action": "exec", "command": "/nix/store/abc/bin/testplugged", "args": ["first", "second"], "env": ["NIX_PATH=x"]}`,
+			nil,
+			[]string{"/nix/store/abc/bin/testplugged first second"},
+			[]string{"NIX_PATH=x"},
+		},
+		{
+			`{"action": "skip", "reason": "nix not installed"}`,
+			&SkipError{"nix not installed"},
+			nil,
+			nil,
+		},
+		{
+			`{"action": "error", "error": "attribute not found"}`,
+			fmt.Errorf("plugin holen-strategy-nix: attribute not found"),
+			nil,
+			nil,
+		},
+		{
+			`{"action": "dance"}`,
+			fmt.Errorf("unknown action 'dance' from plugin holen-strategy-nix"),
+			nil,
+			nil,
+		},
+		{
+			`{"action": "exec"}`,
+			fmt.Errorf("plugin holen-strategy-nix did not give a command to run"),
+			nil,
+			nil,
+		},
+	}
+
+	for _, test := range pluginTests {
+		tu, ps := newPluginStrategy()
+		tu.MemSystem.Setenv("PATH", tempdir)
+		tu.MemRunner.SetOutput(plugin, test.response)
+
+		assert.Equal(test.err, ps.Run([]string{"first", "second"}))
+		assert.Equal(test.history, tu.MemRunner.History)
+		if test.env != nil {
+			assert.Equal(test.env, tu.MemRunner.HistoryEnv[test.history[0]])
+		}
+
+		var request PluginRequest
+		assert.Nil(json.Unmarshal([]byte(tu.MemRunner.Inputs[plugin]), &request))
+		assert.Equal(PluginRequest{
+			Protocol: pluginProtocol,
+			Name:     "testplugged",
+			Version:  "2.1",
+			OS:       tu.MemSystem.OS(),
+			Arch:     tu.MemSystem.Arch(),
+			Args:     []string{"first", "second"},
+			Data: map[string]interface{}{
+				"attribute": "nixpkgs.testplugged_2.1",
+				"channels":  []interface{}{"nixos-2.1"},
+				"flakes":    true,
+			},
+		}, request)
+	}
+}
+
+func TestPluginFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	plugin := path.Join(tempdir, "holen-strategy-nix")
+	ioutil.WriteFile(plugin, []byte{}, 0755)
+
+	tu, ps := newPluginStrategy()
+	tu.MemSystem.Setenv("PATH", tempdir)
+
+	tu.MemRunner.SetOutput(plugin, "not json")
+	err := ps.Run([]string{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "unable to decode response from holen-strategy-nix")
+
+	tu.MemRunner.FailCommand(plugin, fmt.Errorf("exit status 2"))
+	err = ps.Run([]string{})
+	assert.NotNil(err)
+	assert.Equal("plugin holen-strategy-nix failed: exit status 2", err.Error())
+	assert.Empty(tu.MemRunner.History)
+}
+
+func TestPluginNotAvailable(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, ps := newPluginStrategy()
+	tu.MemSystem.Setenv("PATH", tempdir)
+
+	assert.Equal(&SkipError{"plugin holen-strategy-nix not available"}, ps.Run([]string{}))
+	assert.Empty(tu.MemRunner.Inputs)
+}
+
+func TestPluginInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, ps := newPluginStrategy()
+	tu.MemSystem.Setenv("PATH", "")
+	assert.Nil(ps.Inspect())
+
+	assert.Equal([]string{
+		"Plugin Strategy (version: 2.1):\n",
+		"  plugin: holen-strategy-nix (not found)\n",
+		"  attribute: nixpkgs.testplugged_2.1\n",
+		"  channels: [nixos-2.1]\n",
+		"  flakes: true\n",
+	}, tu.MemSystem.StdoutMessages)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+)
+
+// pythonInstalledMarker is written into a virtualenv once everything has
+// been installed, so a partial install is never mistaken for a finished one.
+const pythonInstalledMarker = ".holen-installed"
+
+type PythonData struct {
+	Name           string
+	Desc           string
+	Version        string                       `yaml:"version"`
+	Package        string                       `yaml:"package"`
+	PackageVersion string                       `yaml:"package_version"`
+	Entrypoint     string                       `yaml:"entrypoint"`
+	PythonVersion  string                       `yaml:"python_version"`
+	Requirements   []string                     `yaml:"requirements"`
+	OSArchData     map[string]map[string]string `yaml:"os_arch_map"`
+}
+
+// PythonStrategy installs a Python package into its own virtualenv, like
+// pipx does, and runs its entry point from there.
+type PythonStrategy struct {
+	*StrategyCommon
+	Data PythonData
+}
+
+func (ps PythonStrategy) Version() string {
+	return ps.Data.Version
+}
+
+func (ps PythonStrategy) TemplateValues(values map[string]string) (map[string]string, error) {
+	return ps.CommonTemplateValues(ps.Data.Version, ps.Data.OSArchData, ps.System, values)
+}
+
+func (ps PythonStrategy) templated() (map[string]string, error) {
+	values := map[string]string{
+		"Package":        ps.Data.Package,
+		"PackageVersion": ps.Data.PackageVersion,
+		"Entrypoint":     ps.Data.Entrypoint,
+	}
+	if len(values["Package"]) == 0 {
+		values["Package"] = ps.Data.Name
+	}
+	if len(values["PackageVersion"]) == 0 {
+		values["PackageVersion"] = "{{.Version}}"
+	}
+	if len(values["Entrypoint"]) == 0 {
+		values["Entrypoint"] = ps.Data.Name
+	}
+
+	templated, err := ps.TemplateValues(values)
+	if err != nil {
+		return templated, errors.Wrap(err, fmt.Sprintf("error in templating python version %s", ps.Data.Version))
+	}
+
+	return templated, nil
+}
+
+// VenvPath returns the virtualenv that this version is installed into.
+func (ps PythonStrategy) VenvPath() (string, error) {
+	holenPath, err := ps.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "python", ps.Data.Name, ps.Data.Version), nil
+}
+
+func (ps PythonStrategy) venvBin(venvPath, name string) string {
+	if ps.OS() == "windows" {
+		return filepath.Join(venvPath, "Scripts", fmt.Sprintf("%s.exe", name))
+	}
+	return filepath.Join(venvPath, "bin", name)
+}
+
+// Interpreter finds a python that can create virtualenvs and satisfies the
+// python_version constraint.  The python.interpreter setting overrides the
+// interpreters that are tried.
+func (ps PythonStrategy) Interpreter() (string, error) {
+	candidates := []string{"python3", "python"}
+	if configInterpreter, err := ps.Get("python.interpreter"); err == nil && len(configInterpreter) > 0 {
+		candidates = []string{configInterpreter}
+	}
+
+	var constraint goversion.Constraints
+	if len(ps.Data.PythonVersion) > 0 {
+		var err error
+		constraint, err = goversion.NewConstraint(ps.Data.PythonVersion)
+		if err != nil {
+			return "", errors.Wrap(err, fmt.Sprintf("invalid python_version %s", ps.Data.PythonVersion))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if !ps.CheckCommand(candidate, []string{"-m", "venv", "--help"}) {
+			ps.Debugf("%s not available or can't create virtualenvs", candidate)
+			continue
+		}
+
+		if constraint != nil {
+			output, err := ps.CommandOutput(candidate, []string{"-c", "import platform; print(platform.python_version())"})
+			if err != nil {
+				ps.Debugf("unable to get version of %s: %s", candidate, err)
+				continue
+			}
+
+			pythonVersion, err := goversion.NewVersion(output)
+			if err != nil || !constraint.Check(pythonVersion) {
+				ps.Debugf("%s version %s doesn't satisfy %s", candidate, output, ps.Data.PythonVersion)
+				continue
+			}
+		}
+
+		return candidate, nil
+	}
+
+	return "", &SkipError{"no suitable python interpreter available"}
+}
+
+func (ps PythonStrategy) Run(args []string) error {
+	localPath, err := ps.Install()
+	if err != nil {
+		return err
+	}
+
+	ps.RecordUsage(ps.Data.Name, ps.Data.Version, "", "")
+	err = ps.ExecCommand(localPath, args)
+	if err != nil {
+		return errors.Wrap(err, "can't run entry point")
+	}
+
+	return nil
+}
+
+// Install creates the virtualenv and installs the package into it, unless
+// that's already been done, and returns the location of the entry point.
+func (ps PythonStrategy) Install() (string, error) {
+	templated, err := ps.templated()
+	if err != nil {
+		return "", err
+	}
+
+	venvPath, err := ps.VenvPath()
+	if err != nil {
+		return "", err
+	}
+	localPath := ps.venvBin(venvPath, templated["Entrypoint"])
+	markerPath := filepath.Join(venvPath, pythonInstalledMarker)
+
+	if ps.FileExists(markerPath) {
+		return localPath, nil
+	}
+
+	pkg := fmt.Sprintf("%s==%s", templated["Package"], templated["PackageVersion"])
+	if len(ps.Data.Requirements) > 0 && !requirementsPin(ps.Data.Requirements, templated["Package"], templated["PackageVersion"]) {
+		return "", fmt.Errorf("requirements for %s don't pin %s", ps.Data.Name, pkg)
+	}
+
+	interpreter, err := ps.Interpreter()
+	if err != nil {
+		return "", err
+	}
+
+	// start over if a previous install didn't finish
+	err = os.RemoveAll(venvPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to remove partial virtualenv")
+	}
+	os.MkdirAll(filepath.Dir(venvPath), 0755)
+
+	ps.Stderrf("Installing %s...\n", pkg)
+
+	err = ps.RunCommand(interpreter, []string{"-m", "venv", venvPath})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to create virtualenv")
+	}
+	os.MkdirAll(venvPath, 0755)
+
+	pipArgs := []string{"-m", "pip", "install", "--quiet", "--disable-pip-version-check"}
+	if len(ps.Data.Requirements) > 0 {
+		requirementsPath := filepath.Join(venvPath, "holen-requirements.txt")
+		err = ioutil.WriteFile(requirementsPath, []byte(strings.Join(ps.Data.Requirements, "\n")+"\n"), 0644)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to write requirements")
+		}
+		pipArgs = append(pipArgs, "--require-hashes", "-r", requirementsPath)
+	} else {
+		pipArgs = append(pipArgs, pkg)
+	}
+
+	err = ps.RunCommand(ps.venvBin(venvPath, "python"), pipArgs)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to install %s", pkg))
+	}
+
+	err = ioutil.WriteFile(markerPath, []byte(pkg+"\n"), 0644)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to mark virtualenv as installed")
+	}
+
+	return localPath, nil
+}
+
+// requirementsPin reports whether one of the requirements pins the package
+// to exactly the version, so that hashed requirements can't quietly install
+// something else.
+func requirementsPin(requirements []string, pkg, version string) bool {
+	for _, requirement := range requirements {
+		fields := strings.Fields(strings.SplitN(requirement, ";", 2)[0])
+		if len(fields) == 0 {
+			continue
+		}
+
+		parts := strings.SplitN(fields[0], "==", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.SplitN(parts[0], "[", 2)[0]
+		if pythonPackageName(name) == pythonPackageName(pkg) && parts[1] == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pythonPackageName normalizes a package name the way pip compares them.
+func pythonPackageName(name string) string {
+	return strings.NewReplacer("_", "-", ".", "-").Replace(strings.ToLower(name))
+}
+
+func (ps PythonStrategy) Inspect() error {
+	return ps.showInspection(ps.Inspection())
+}
+
+func (ps PythonStrategy) Inspection() (*Inspection, error) {
+	templated, err := ps.templated()
+	if err != nil {
+		return nil, err
+	}
+
+	venvPath, err := ps.VenvPath()
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := newInspection("python", "Python Strategy", ps.Data.Version)
+	inspection.Add("package", "package", fmt.Sprintf("%s==%s", templated["Package"], templated["PackageVersion"]))
+	if len(ps.Data.PythonVersion) > 0 {
+		inspection.Add("python_version", "python version", ps.Data.PythonVersion)
+	}
+	if len(ps.Data.Requirements) > 0 {
+		inspection.Add("hashed_requirements", "hashed requirements", len(ps.Data.Requirements))
+	}
+	inspection.Add("entry_point", "entry point", ps.venvBin(venvPath, templated["Entrypoint"]))
+
+	return inspection, nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPythonStrategy() (*TestUtils, *PythonStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+	tu.MemSystem.MOS = "linux"
+
+	return tu, &PythonStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: PythonData{
+			Name:       "testpy",
+			Desc:       "Test Python Program",
+			Version:    "2.1",
+			OSArchData: make(map[string]map[string]string),
+		},
+	}
+}
+
+func TestPythonInstall(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	venv := path.Join(tempdir, "holen/python/testpy/2.1")
+
+	var installTests = []struct {
+		adjustment func(*TestUtils, *PythonStrategy)
+		history    []string
+	}{
+		{
+			nil,
+			[]string{
+				fmt.Sprintf("python3 -m venv %s", venv),
+				fmt.Sprintf("%s/bin/python -m pip install --quiet --disable-pip-version-check testpy==2.1", venv),
+				fmt.Sprintf("%s/bin/testpy first second", venv),
+			},
+		},
+		{
+			func(tu *TestUtils, tp *PythonStrategy) {
+				tu.MemRunner.FailCheck("python3 -m venv --help")
+				tp.Data.Package = "test-py"
+				tp.Data.PackageVersion = "{{.Version}}.post1"
+				tp.Data.Entrypoint = "tpy"
+			},
+			[]string{
+				fmt.Sprintf("python -m venv %s", venv),
+				fmt.Sprintf("%s/bin/python -m pip install --quiet --disable-pip-version-check test-py==2.1.post1", venv),
+				fmt.Sprintf("%s/bin/tpy first second", venv),
+			},
+		},
+		{
+			func(tu *TestUtils, tp *PythonStrategy) {
+				tu.MemConfig.Set(false, "python.interpreter", "python3.11")
+				tp.Data.Requirements = []string{"testpy==2.1 --hash=sha256:abcd"}
+			},
+			[]string{
+				fmt.Sprintf("python3.11 -m venv %s", venv),
+				fmt.Sprintf("%s/bin/python -m pip install --quiet --disable-pip-version-check --require-hashes -r %s/holen-requirements.txt", venv, venv),
+				fmt.Sprintf("%s/bin/testpy first second", venv),
+			},
+		},
+		{
+			func(tu *TestUtils, tp *PythonStrategy) {
+				tp.Data.PythonVersion = ">= 3.8"
+				tu.MemRunner.SetOutput("python3 -c import platform; print(platform.python_version())", "3.6.9")
+				tu.MemRunner.SetOutput("python -c import platform; print(platform.python_version())", "3.10.4")
+			},
+			[]string{
+				fmt.Sprintf("python -m venv %s", venv),
+				fmt.Sprintf("%s/bin/python -m pip install --quiet --disable-pip-version-check testpy==2.1", venv),
+				fmt.Sprintf("%s/bin/testpy first second", venv),
+			},
+		},
+	}
+
+	for _, test := range installTests {
+		tu, tp := newPythonStrategy()
+		tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+		if test.adjustment != nil {
+			test.adjustment(tu, tp)
+		}
+
+		assert.Nil(tp.Run([]string{"first", "second"}))
+		assert.Equal(test.history, tu.MemRunner.History)
+
+		marker, err := ioutil.ReadFile(path.Join(venv, pythonInstalledMarker))
+		assert.Nil(err)
+		assert.Contains(string(marker), "==")
+
+		if len(tp.Data.Requirements) > 0 {
+			requirements, _ := ioutil.ReadFile(path.Join(venv, "holen-requirements.txt"))
+			assert.Equal("testpy==2.1 --hash=sha256:abcd\n", string(requirements))
+		}
+	}
+}
+
+func TestPythonInstalled(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tp := newPythonStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", "/tmp")
+	tu.MemSystem.Files["/tmp/holen/python/testpy/2.1/"+pythonInstalledMarker] = true
+
+	assert.Nil(tp.Run([]string{}))
+	assert.Equal([]string{"/tmp/holen/python/testpy/2.1/bin/testpy"}, tu.MemRunner.History)
+}
+
+func TestPythonNoInterpreter(t *testing.T) {
+	assert := assert.New(t)
+
+	var interpreterTests = []struct {
+		adjustment func(*TestUtils, *PythonStrategy)
+	}{
+		{
+			func(tu *TestUtils, tp *PythonStrategy) {
+				tu.MemRunner.FailCheck("python3 -m venv --help")
+				tu.MemRunner.FailCheck("python -m venv --help")
+			},
+		},
+		{
+			func(tu *TestUtils, tp *PythonStrategy) {
+				tp.Data.PythonVersion = ">= 3.8"
+				tu.MemRunner.SetOutput("python3 -c import platform; print(platform.python_version())", "3.6.9")
+				tu.MemRunner.SetOutput("python -c import platform; print(platform.python_version())", "2.7.18")
+			},
+		},
+	}
+
+	for _, test := range interpreterTests {
+		tu, tp := newPythonStrategy()
+		tu.MemSystem.Setenv("XDG_DATA_HOME", "/tmp")
+		test.adjustment(tu, tp)
+
+		err := tp.Run([]string{})
+		assert.Equal(&SkipError{"no suitable python interpreter available"}, err)
+		assert.Empty(tu.MemRunner.History)
+	}
+}
+
+func TestPythonInstallFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	venv := path.Join(tempdir, "holen/python/testpy/2.1")
+
+	tu, tp := newPythonStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+	tu.MemRunner.FailCommand(fmt.Sprintf("%s/bin/python -m pip install --quiet --disable-pip-version-check testpy==2.1", venv), fmt.Errorf("no such package"))
+
+	assert.NotNil(tp.Run([]string{}))
+	_, err := os.Stat(path.Join(venv, pythonInstalledMarker))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestPythonInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tp := newPythonStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", "/tmp")
+	tp.Data.PythonVersion = ">= 3.8"
+	assert.Nil(tp.Inspect())
+
+	assert.Equal([]string{
+		"Python Strategy (version: 2.1):\n",
+		"  package: testpy==2.1\n",
+		"  python version: >= 3.8\n",
+		"  entry point: /tmp/holen/python/testpy/2.1/bin/testpy\n",
+	}, tu.MemSystem.StdoutMessages)
+}
+
+func TestPythonRequirementsPin(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		requirements []string
+		err          string
+	}{
+		{[]string{"click==8.1.7 --hash=sha256:ae74", "testpy==2.1 --hash=sha256:abcd"}, ""},
+		{[]string{"TestPy[extra]==2.1 --hash=sha256:abcd ; python_version >= '3.8'"}, ""},
+		{[]string{"testpy==2.0 --hash=sha256:abcd"}, "requirements for testpy don't pin testpy==2.1"},
+		{[]string{"testpy>=2.1 --hash=sha256:abcd"}, "requirements for testpy don't pin testpy==2.1"},
+		{[]string{"click==8.1.7 --hash=sha256:ae74"}, "requirements for testpy don't pin testpy==2.1"},
+	}
+
+	for _, test := range tests {
+		tempdir, _ := ioutil.TempDir("", "holen")
+		defer os.RemoveAll(tempdir)
+
+		tu, tp := newPythonStrategy()
+		tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+		tp.Data.Requirements = test.requirements
+
+		err := tp.Run([]string{})
+		if len(test.err) > 0 {
+			assert.EqualError(err, test.err)
+			assert.Empty(tu.MemRunner.History)
+		} else {
+			assert.Nil(err)
+		}
+	}
+}
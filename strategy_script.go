@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+type ScriptData struct {
+	Name            string
+	Desc            string
+	Version         string                       `yaml:"version"`
+	URL             string                       `yaml:"url"`
+	Interpreter     string                       `yaml:"interpreter"`
+	InterpreterArgs []string                     `yaml:"interpreter_args"`
+	OSArchData      map[string]map[string]string `yaml:"os_arch_map"`
+	Checksums       map[string]string
+}
+
+// ScriptStrategy downloads a single script and runs it with an interpreter
+// that's already installed.
+type ScriptStrategy struct {
+	*StrategyCommon
+	Data ScriptData
+}
+
+func (ss ScriptStrategy) Version() string {
+	return ss.Data.Version
+}
+
+func (ss ScriptStrategy) TemplateValues(values map[string]string) (map[string]string, error) {
+	return ss.CommonTemplateValues(ss.Data.Version, ss.Data.OSArchData, ss.System, values)
+}
+
+func (ss ScriptStrategy) templated() (map[string]string, error) {
+	templated, err := ss.TemplateValues(map[string]string{
+		"URL":         ss.Data.URL,
+		"Interpreter": ss.Data.Interpreter,
+	})
+	if err != nil {
+		return templated, errors.Wrap(err, fmt.Sprintf("error in templating script version %s", ss.Data.Version))
+	}
+
+	return templated, nil
+}
+
+// ScriptPath returns where the script for this version is cached.  The
+// extension is kept, since some interpreters care about it.
+func (ss ScriptStrategy) ScriptPath(scriptURL string) (string, error) {
+	holenPath, err := ss.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	u, err := url.Parse(scriptURL)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to parse url")
+	}
+
+	scriptsPath := filepath.Join(holenPath, "scripts")
+	os.MkdirAll(scriptsPath, 0755)
+
+	return filepath.Join(scriptsPath, fmt.Sprintf("%s--%s%s", ss.Data.Name, ss.Data.Version, path.Ext(u.Path))), nil
+}
+
+// FindInterpreter returns the full path to the interpreter.
+func (ss ScriptStrategy) FindInterpreter(interpreter string) (string, error) {
+	found := lookPath(ss.Getenv("PATH"), interpreter)
+	if len(found) == 0 {
+		return "", &SkipError{fmt.Sprintf("interpreter %s not available", interpreter)}
+	}
+
+	return found[0], nil
+}
+
+func (ss ScriptStrategy) Run(args []string) error {
+	templated, err := ss.templated()
+	if err != nil {
+		return err
+	}
+
+	interpreter, err := ss.FindInterpreter(templated["Interpreter"])
+	if err != nil {
+		return err
+	}
+
+	scriptPath, err := ss.Install()
+	if err != nil {
+		return err
+	}
+
+	ss.RecordUsage(ss.Data.Name, ss.Data.Version, "", "")
+	fullArgs := append(append([]string{}, ss.Data.InterpreterArgs...), scriptPath)
+	err = ss.ExecCommand(interpreter, append(fullArgs, args...))
+	if err != nil {
+		return errors.Wrap(err, "can't run script")
+	}
+
+	return nil
+}
+
+// Install downloads and checks the script, unless it's already cached, and
+// returns its location.
+func (ss ScriptStrategy) Install() (string, error) {
+	templated, err := ss.templated()
+	if err != nil {
+		return "", err
+	}
+
+	scriptPath, err := ss.ScriptPath(templated["URL"])
+	if err != nil {
+		return "", err
+	}
+
+	if ss.FileExists(scriptPath) {
+		return scriptPath, nil
+	}
+
+	tempPath, err := ss.TempPath()
+	if err != nil {
+		return "", err
+	}
+	tempdir, err := ioutil.TempDir(tempPath, "holen")
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make temporary directory")
+	}
+	defer os.RemoveAll(tempdir)
+
+	downloadPath := filepath.Join(tempdir, filepath.Base(scriptPath))
+
+	ss.Stderrf("Downloading %s...\n", templated["URL"])
+	err = ss.DownloadFile(templated["URL"], downloadPath)
+	if err != nil {
+		return "", errors.Wrap(err, "can't download script")
+	}
+
+	err = ss.ChecksumFile(downloadPath, ss.ChecksumData(ss.Data.Checksums, ss.Data.OSArchData))
+	if err != nil {
+		if err == NoCheckSums {
+			ss.Debugf("skipping checksum, no checksums provided")
+		} else {
+			return "", errors.Wrap(err, "script checksum failed")
+		}
+	}
+
+	err = os.Rename(downloadPath, scriptPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to move script into position")
+	}
+
+	return scriptPath, nil
+}
+
+func (ss ScriptStrategy) Inspect() error {
+	return ss.showInspection(ss.Inspection())
+}
+
+func (ss ScriptStrategy) Inspection() (*Inspection, error) {
+	templated, err := ss.templated()
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := newInspection("script", "Script Strategy", ss.Data.Version)
+	inspection.Add("final_url", "final url", templated["URL"])
+	inspection.Add("interpreter", "interpreter", templated["Interpreter"])
+	inspection.AddChecksum(ss.FindChecksum(ss.ChecksumData(ss.Data.Checksums, ss.Data.OSArchData)))
+
+	return inspection, nil
+}
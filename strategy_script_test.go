@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newScriptStrategy() (*TestUtils, *ScriptStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+
+	return tu, &ScriptStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: ScriptData{
+			Name:        "testscript",
+			Desc:        "Test Script",
+			Version:     "0.3",
+			URL:         "https://scripts.example.com/testscript-{{.Version}}.sh",
+			Interpreter: "bash",
+			OSArchData:  map[string]map[string]string{},
+		},
+	}
+}
+
+func TestScriptRun(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	binDir := path.Join(tempdir, "bin")
+	os.MkdirAll(binDir, 0755)
+	ioutil.WriteFile(path.Join(binDir, "bash"), []byte{}, 0755)
+	ioutil.WriteFile(path.Join(binDir, "python3"), []byte{}, 0755)
+	scriptsDir := path.Join(tempdir, "holen", "scripts")
+
+	var scriptTests = []struct {
+		adjustment func(*TestUtils, *ScriptStrategy)
+		history    []string
+		downloaded bool
+	}{
+		{
+			nil,
+			[]string{fmt.Sprintf("%s/bash %s/testscript--0.3.sh first second", binDir, scriptsDir)},
+			true,
+		},
+		{
+			func(tu *TestUtils, ts *ScriptStrategy) {
+				ts.Data.URL = "https://scripts.example.com/testscript"
+				ts.Data.Interpreter = "python3"
+				ts.Data.InterpreterArgs = []string{"-u"}
+				// md5 of an empty file
+				ts.Data.Checksums = map[string]string{"md5sum": "d41d8cd98f00b204e9800998ecf8427e"}
+			},
+			[]string{fmt.Sprintf("%s/python3 -u %s/testscript--0.3 first second", binDir, scriptsDir)},
+			true,
+		},
+		{
+			func(tu *TestUtils, ts *ScriptStrategy) {
+				tu.MemSystem.Files[path.Join(scriptsDir, "testscript--0.3.sh")] = true
+			},
+			[]string{fmt.Sprintf("%s/bash %s/testscript--0.3.sh first second", binDir, scriptsDir)},
+			false,
+		},
+	}
+
+	for _, test := range scriptTests {
+		tu, ts := newScriptStrategy()
+		tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+		tu.MemSystem.Setenv("PATH", binDir)
+		if test.adjustment != nil {
+			test.adjustment(tu, ts)
+		}
+
+		assert.Nil(ts.Run([]string{"first", "second"}))
+		assert.Equal(test.history, tu.MemRunner.History)
+		assert.Equal(test.downloaded, len(tu.MemDownloader.Files) > 0)
+	}
+}
+
+func TestScriptNoInterpreter(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, ts := newScriptStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+	tu.MemSystem.Setenv("PATH", tempdir)
+
+	assert.Equal(&SkipError{"interpreter bash not available"}, ts.Run([]string{}))
+	assert.Empty(tu.MemRunner.History)
+	assert.Empty(tu.MemDownloader.Files)
+}
+
+func TestScriptChecksumFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	ioutil.WriteFile(path.Join(tempdir, "bash"), []byte{}, 0755)
+
+	tu, ts := newScriptStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+	tu.MemSystem.Setenv("PATH", tempdir)
+	ts.Data.Checksums = map[string]string{"md5sum": "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"}
+
+	err := ts.Run([]string{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "script checksum failed")
+	assert.Empty(tu.MemRunner.History)
+
+	_, err = os.Stat(path.Join(tempdir, "holen", "scripts", "testscript--0.3.sh"))
+	assert.True(os.IsNotExist(err))
+}
+
+func TestScriptInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, ts := newScriptStrategy()
+	ts.Data.Checksums = map[string]string{"sha256sum": "abcd"}
+	assert.Nil(ts.Inspect())
+
+	assert.Equal([]string{
+		"Script Strategy (version: 0.3):\n",
+		"  final url: https://scripts.example.com/testscript-0.3.sh\n",
+		"  interpreter: bash\n",
+		"  checksum with sha256: abcd\n",
+	}, tu.MemSystem.StdoutMessages)
+}
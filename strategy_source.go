@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+type SourceData struct {
+	Name       string
+	Desc       string
+	Version    string                       `yaml:"version"`
+	URL        string                       `yaml:"url"`
+	SourcePath string                       `yaml:"source_path"`
+	Build      []string                     `yaml:"build"`
+	Artifact   string                       `yaml:"artifact"`
+	OSArchData map[string]map[string]string `yaml:"os_arch_map"`
+	Checksums  map[string]string
+}
+
+// SourceStrategy builds the utility from a source archive, for platforms
+// that nobody publishes binaries for.
+type SourceStrategy struct {
+	*StrategyCommon
+	Data SourceData
+}
+
+func (ss SourceStrategy) Version() string {
+	return ss.Data.Version
+}
+
+func (ss SourceStrategy) TemplateValues(values map[string]string) (map[string]string, error) {
+	return ss.CommonTemplateValues(ss.Data.Version, ss.Data.OSArchData, ss.System, values)
+}
+
+func (ss SourceStrategy) templated() (map[string]string, error) {
+	values := map[string]string{
+		"URL":        ss.Data.URL,
+		"SourcePath": ss.Data.SourcePath,
+		"Artifact":   ss.Data.Artifact,
+	}
+	for i, step := range ss.Data.Build {
+		values[fmt.Sprintf("Build%d", i)] = step
+	}
+
+	templated, err := ss.TemplateValues(values)
+	if err != nil {
+		return templated, errors.Wrap(err, fmt.Sprintf("error in templating source version %s", ss.Data.Version))
+	}
+
+	return templated, nil
+}
+
+func (ss SourceStrategy) buildSteps(templated map[string]string) []string {
+	steps := make([]string, len(ss.Data.Build))
+	for i := range ss.Data.Build {
+		steps[i] = templated[fmt.Sprintf("Build%d", i)]
+	}
+	return steps
+}
+
+// BuildPath returns the scratch directory that this version is built in.
+func (ss SourceStrategy) BuildPath() (string, error) {
+	holenPath, err := ss.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "build", fmt.Sprintf("%s--%s", ss.Data.Name, ss.Data.Version)), nil
+}
+
+// cleanOldBuilds removes build directories left over from other versions.
+func (ss SourceStrategy) cleanOldBuilds(buildPath string) {
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(buildPath), fmt.Sprintf("%s--*", ss.Data.Name)))
+	for _, match := range matches {
+		if match != buildPath {
+			ss.Debugf("removing old build %s", match)
+			os.RemoveAll(match)
+		}
+	}
+}
+
+func (ss SourceStrategy) Run(args []string) error {
+	localPath, err := ss.Install()
+	if err != nil {
+		return err
+	}
+
+	ss.RecordUsage(ss.Data.Name, ss.Data.Version, "", "")
+	err = ss.ExecCommand(localPath, args)
+	if err != nil {
+		return errors.Wrap(err, "can't run binary")
+	}
+
+	return nil
+}
+
+// Install builds the utility and puts the artifact in the download path,
+// unless it's already there, and returns its location.  The build
+// directory and its build.log are kept until a different version is built.
+func (ss SourceStrategy) Install() (string, error) {
+	templated, err := ss.templated()
+	if err != nil {
+		return "", err
+	}
+
+	downloadPath, err := ss.DownloadPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to find download path")
+	}
+	localPath := filepath.Join(downloadPath, fmt.Sprintf("%s--%s", ss.Data.Name, ss.Data.Version))
+
+	if ss.FileExists(localPath) {
+		return localPath, nil
+	}
+
+	shell := lookPath(ss.Getenv("PATH"), "sh")
+	if len(shell) == 0 {
+		return "", &SkipError{"sh not available to run build"}
+	}
+
+	buildPath, err := ss.BuildPath()
+	if err != nil {
+		return "", err
+	}
+	ss.cleanOldBuilds(buildPath)
+
+	err = os.RemoveAll(buildPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to remove previous build")
+	}
+	err = os.MkdirAll(buildPath, 0755)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make build directory")
+	}
+
+	u, err := url.Parse(templated["URL"])
+	if err != nil {
+		return "", errors.Wrap(err, "unable to parse url")
+	}
+	archivePath := filepath.Join(buildPath, path.Base(u.Path))
+
+	ss.Stderrf("Downloading %s...\n", templated["URL"])
+	err = ss.DownloadFile(templated["URL"], archivePath)
+	if err != nil {
+		return "", errors.Wrap(err, "can't download source")
+	}
+
+	err = ss.ChecksumFile(archivePath, ss.ChecksumData(ss.Data.Checksums, ss.Data.OSArchData))
+	if err != nil {
+		if err == NoCheckSums {
+			ss.Debugf("skipping checksum, no checksums provided")
+		} else {
+			return "", errors.Wrap(err, "source checksum failed")
+		}
+	}
+
+	unpackedPath := filepath.Join(buildPath, "src")
+	err = ss.UnpackArchiveWithOptions(archivePath, unpackedPath, ArchiveOptions{MaxSize: ss.MaxArchiveSize()})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to unpack source")
+	}
+
+	sourcePath := filepath.Join(unpackedPath, templated["SourcePath"])
+	if len(templated["SourcePath"]) == 0 {
+		sourcePath = singleDirectory(unpackedPath)
+	}
+
+	logPath := filepath.Join(buildPath, "build.log")
+	ss.Stderrf("Building %s %s, logging to %s...\n", ss.Data.Name, ss.Data.Version, logPath)
+	for _, step := range ss.buildSteps(templated) {
+		err = appendFile(logPath, fmt.Sprintf("$ %s\n", step))
+		if err != nil {
+			return "", errors.Wrap(err, "unable to write build log")
+		}
+
+		err = ss.RunCommandInDir(sourcePath, shell[0], []string{"-c", step}, logPath)
+		if err != nil {
+			return "", fmt.Errorf("build step '%s' failed, see %s", step, logPath)
+		}
+	}
+
+	artifactPath := filepath.Join(sourcePath, templated["Artifact"])
+	err = os.Rename(artifactPath, localPath)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to install %s", templated["Artifact"]))
+	}
+
+	err = ss.MakeExecutable(localPath)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to make binary executable")
+	}
+	ss.RecordDownload(localPath)
+
+	return localPath, nil
+}
+
+// singleDirectory returns the directory that everything was unpacked into,
+// if there's only one, since most source archives are laid out that way.
+func singleDirectory(unpackedPath string) string {
+	entries, err := ioutil.ReadDir(unpackedPath)
+	if err == nil && len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(unpackedPath, entries[0].Name())
+	}
+	return unpackedPath
+}
+
+func appendFile(filePath, contents string) error {
+	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(contents)
+	return err
+}
+
+func (ss SourceStrategy) Inspect() error {
+	return ss.showInspection(ss.Inspection())
+}
+
+func (ss SourceStrategy) Inspection() (*Inspection, error) {
+	templated, err := ss.templated()
+	if err != nil {
+		return nil, err
+	}
+
+	buildPath, err := ss.BuildPath()
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := newInspection("source", "Source Strategy", ss.Data.Version)
+	inspection.Add("final_url", "final url", templated["URL"])
+	inspection.AddChecksum(ss.FindChecksum(ss.ChecksumData(ss.Data.Checksums, ss.Data.OSArchData)))
+	inspection.AddList("build_steps", "build steps", ss.buildSteps(templated))
+	inspection.Add("artifact", "artifact", templated["Artifact"])
+	inspection.Add("build_log", "build log", filepath.Join(buildPath, "build.log"))
+
+	return inspection, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSourceStrategy(tempdir string) (*TestUtils, *SourceStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+
+	binDir := path.Join(tempdir, "bin")
+	os.MkdirAll(binDir, 0755)
+	ioutil.WriteFile(path.Join(binDir, "sh"), []byte{}, 0755)
+	tu.MemSystem.Setenv("PATH", binDir)
+	tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+	tu.MemSystem.ArchiveFiles["testsource-1.0.tar.gz"] = []string{"testsource"}
+
+	return tu, &SourceStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: SourceData{
+			Name:       "testsource",
+			Desc:       "Test Source Program",
+			Version:    "1.0",
+			URL:        "https://src.example.com/testsource-{{.Version}}.tar.gz",
+			Build:      []string{"./configure --prefix=/usr", "make {{.Version}}"},
+			Artifact:   "testsource",
+			OSArchData: map[string]map[string]string{},
+		},
+	}
+}
+
+func TestSourceBuild(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	oldBuild := path.Join(tempdir, "holen/build/testsource--0.9")
+	os.MkdirAll(oldBuild, 0755)
+
+	tu, ts := newSourceStrategy(tempdir)
+	assert.Nil(ts.Run([]string{"first", "second"}))
+
+	sh := path.Join(tempdir, "bin/sh")
+	buildPath := path.Join(tempdir, "holen/build/testsource--1.0")
+	localPath := path.Join(tempdir, "holen/bin/testsource--1.0")
+	assert.Equal([]string{
+		fmt.Sprintf("%s -c ./configure --prefix=/usr", sh),
+		fmt.Sprintf("%s -c make 1.0", sh),
+		fmt.Sprintf("%s first second", localPath),
+	}, tu.MemRunner.History)
+	assert.Equal(path.Join(buildPath, "src"), tu.MemRunner.HistoryDir[fmt.Sprintf("%s -c make 1.0", sh)])
+
+	_, err := os.Stat(localPath)
+	assert.Nil(err)
+
+	buildLog, _ := ioutil.ReadFile(path.Join(buildPath, "build.log"))
+	assert.Equal("$ ./configure --prefix=/usr\n$ make 1.0\n", string(buildLog))
+
+	_, err = os.Stat(oldBuild)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestSourceBuilt(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, ts := newSourceStrategy(tempdir)
+	localPath := path.Join(tempdir, "holen/bin/testsource--1.0")
+	tu.MemSystem.Files[localPath] = true
+
+	assert.Nil(ts.Run([]string{}))
+	assert.Equal([]string{localPath}, tu.MemRunner.History)
+	assert.Empty(tu.MemDownloader.Files)
+}
+
+func TestSourceBuildFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, ts := newSourceStrategy(tempdir)
+	tu.MemRunner.FailCommand(fmt.Sprintf("%s -c ./configure --prefix=/usr", path.Join(tempdir, "bin/sh")), fmt.Errorf("exit status 1"))
+
+	err := ts.Run([]string{})
+	assert.NotNil(err)
+	assert.Equal(fmt.Sprintf("build step './configure --prefix=/usr' failed, see %s", path.Join(tempdir, "holen/build/testsource--1.0/build.log")), err.Error())
+	assert.Len(tu.MemRunner.History, 1)
+}
+
+func TestSourceNoShell(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, ts := newSourceStrategy(tempdir)
+	tu.MemSystem.Setenv("PATH", "")
+
+	assert.Equal(&SkipError{"sh not available to run build"}, ts.Run([]string{}))
+	assert.Empty(tu.MemDownloader.Files)
+}
+
+func TestSourceChecksumFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, ts := newSourceStrategy(tempdir)
+	ts.Data.Checksums = map[string]string{"sha1sum": "xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"}
+
+	err := ts.Run([]string{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "source checksum failed")
+	assert.Empty(tu.MemRunner.History)
+}
+
+func TestSourceInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, ts := newSourceStrategy("/tmp")
+	assert.Nil(ts.Inspect())
+
+	assert.Equal([]string{
+		"Source Strategy (version: 1.0):\n",
+		"  final url: https://src.example.com/testsource-1.0.tar.gz\n",
+		"  build steps:\n",
+		"    ./configure --prefix=/usr\n",
+		"    make 1.0\n",
+		"  artifact: testsource\n",
+		"  build log: /tmp/holen/build/testsource--1.0/build.log\n",
+	}, tu.MemSystem.StdoutMessages)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type SSHData struct {
+	Name       string
+	Desc       string
+	Version    string                       `yaml:"version"`
+	Host       string                       `yaml:"host"`
+	User       string                       `yaml:"user"`
+	Port       string                       `yaml:"port"`
+	Identity   string                       `yaml:"identity"`
+	Command    string                       `yaml:"command"`
+	Terminal   string                       `yaml:"terminal"`
+	Stdin      bool                         `yaml:"stdin"`
+	SyncPwd    bool                         `yaml:"sync_pwd"`
+	RemoteDir  string                       `yaml:"remote_dir"`
+	OSArchData map[string]map[string]string `yaml:"os_arch_map"`
+}
+
+// SSHStrategy runs the utility on another machine over ssh, optionally
+// copying the current directory over first.
+type SSHStrategy struct {
+	*StrategyCommon
+	Data SSHData
+
+	// pinned strategies always connect where the data says, ignoring config
+	pinned bool
+}
+
+// sshOverrides are the settings that can be replaced in config with
+// ssh.<name>.<setting> or ssh.<setting>, so a manifest doesn't need to know
+// which machine it'll be run on.
+var sshOverrides = []string{"host", "user", "port", "identity", "command"}
+
+var sshPort = regexp.MustCompile(`^[0-9]+$`)
+
+func (ss SSHStrategy) Version() string {
+	return ss.Data.Version
+}
+
+func (ss SSHStrategy) TemplateValues(values map[string]string) (map[string]string, error) {
+	return ss.CommonTemplateValues(ss.Data.Version, ss.Data.OSArchData, ss.System, values)
+}
+
+// Settings returns the templated connection settings, with any overrides
+// from config applied.
+func (ss SSHStrategy) Settings() (map[string]string, error) {
+	command := ss.Data.Command
+	if len(command) == 0 {
+		command = ss.Data.Name
+	}
+	remoteDir := ss.Data.RemoteDir
+	if len(remoteDir) == 0 {
+		remoteDir = fmt.Sprintf(".holen/sync/%s", ss.Data.Name)
+	}
+
+	settings, err := ss.TemplateValues(map[string]string{
+		"host":       ss.Data.Host,
+		"user":       ss.Data.User,
+		"port":       ss.Data.Port,
+		"identity":   ss.Data.Identity,
+		"command":    command,
+		"remote_dir": remoteDir,
+	})
+	if err != nil {
+		return settings, errors.Wrap(err, fmt.Sprintf("error in templating ssh version %s", ss.Data.Version))
+	}
+
+	for _, key := range sshOverrides {
+		if value, ok := ss.override(key); ok {
+			settings[key] = value
+		}
+	}
+
+	// these end up as arguments to ssh and rsync, so they mustn't be
+	// mistaken for options
+	for _, key := range []string{"host", "user", "port"} {
+		if strings.HasPrefix(settings[key], "-") {
+			return settings, fmt.Errorf("invalid ssh %s %s for %s, it can't start with -", key, settings[key], ss.Data.Name)
+		}
+	}
+	if len(settings["port"]) > 0 && !sshPort.MatchString(settings["port"]) {
+		return settings, fmt.Errorf("invalid ssh port %s for %s", settings["port"], ss.Data.Name)
+	}
+
+	return settings, nil
+}
+
+// override returns the value config gives for a setting, if any.
+func (ss SSHStrategy) override(key string) (string, bool) {
+	if ss.pinned {
+		return "", false
+	}
+
+	for _, configKey := range []string{fmt.Sprintf("ssh.%s.%s", ss.Data.Name, key), fmt.Sprintf("ssh.%s", key)} {
+		if value, err := ss.Get(configKey); err == nil && len(value) > 0 {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func (ss SSHStrategy) destination(settings map[string]string) string {
+	if len(settings["user"]) > 0 {
+		return fmt.Sprintf("%s@%s", settings["user"], settings["host"])
+	}
+	return settings["host"]
+}
+
+func (ss SSHStrategy) connectionArgs(settings map[string]string) []string {
+	args := []string{}
+	if len(settings["port"]) > 0 {
+		args = append(args, "-p", settings["port"])
+	}
+	if len(settings["identity"]) > 0 {
+		args = append(args, "-i", settings["identity"])
+	}
+	return args
+}
+
+// GenerateArgs returns the arguments to ssh.  The remote side runs the
+// command through a shell, so the user's arguments are quoted.
+func (ss SSHStrategy) GenerateArgs(settings map[string]string, args []string) []string {
+	sshArgs := ss.connectionArgs(settings)
+
+	if ss.Data.Terminal == "always" {
+		sshArgs = append(sshArgs, "-t")
+	} else if ss.Data.Terminal == "never" {
+		sshArgs = append(sshArgs, "-T")
+	}
+	if !ss.Data.Stdin {
+		sshArgs = append(sshArgs, "-n")
+	}
+
+	remote := []string{settings["command"]}
+	if ss.Data.SyncPwd {
+		remote = []string{"cd", shellQuote(settings["remote_dir"]), "&&", settings["command"]}
+	}
+	for _, arg := range args {
+		remote = append(remote, shellQuote(arg))
+	}
+
+	return append(sshArgs, "--", ss.destination(settings), strings.Join(remote, " "))
+}
+
+// SyncArgs returns the arguments to rsync for copying the current directory
+// to the remote directory, which is created if needed.  rsync splits the
+// remote shell command itself, so its arguments are quoted.
+func (ss SSHStrategy) SyncArgs(settings map[string]string) []string {
+	rsh := []string{"ssh"}
+	for _, arg := range ss.connectionArgs(settings) {
+		rsh = append(rsh, shellQuote(arg))
+	}
+
+	return []string{
+		"-az", "--delete",
+		"-e", strings.Join(rsh, " "),
+		"--rsync-path", fmt.Sprintf("mkdir -p %s && rsync", shellQuote(settings["remote_dir"])),
+		"--",
+		"./",
+		fmt.Sprintf("%s:%s/", ss.destination(settings), settings["remote_dir"]),
+	}
+}
+
+func (ss SSHStrategy) Run(args []string) error {
+	settings, err := ss.Settings()
+	if err != nil {
+		return err
+	}
+
+	if len(settings["host"]) == 0 {
+		return &SkipError{fmt.Sprintf("no host configured, set ssh.%s.host", ss.Data.Name)}
+	}
+
+	if !ss.CheckCommand("ssh", []string{"-V"}) {
+		return &SkipError{"ssh not available"}
+	}
+
+	if ss.Data.SyncPwd {
+		if !ss.CheckCommand("rsync", []string{"--version"}) {
+			return &SkipError{"rsync not available"}
+		}
+
+		// the manifest alone shouldn't be able to send the current
+		// directory somewhere the user hasn't chosen
+		if _, ok := ss.override("host"); !ok {
+			err = ss.ApprovePrivileged(ss.Data.Name, ss.Data.Version, []string{fmt.Sprintf("sync_pwd: %s", ss.destination(settings))})
+			if err != nil {
+				return err
+			}
+		}
+
+		ss.Stderrf("Syncing current directory to %s...\n", settings["host"])
+		err = ss.RunCommand("rsync", ss.SyncArgs(settings))
+		if err != nil {
+			return errors.Wrap(err, "unable to sync current directory")
+		}
+	}
+
+	ss.RecordUsage(ss.Data.Name, ss.Data.Version, "", "")
+	err = ss.ExecCommand("ssh", ss.GenerateArgs(settings, args))
+	if err != nil {
+		return errors.Wrap(err, "can't run ssh session")
+	}
+
+	return nil
+}
+
+func (ss SSHStrategy) Inspect() error {
+	return ss.showInspection(ss.Inspection())
+}
+
+func (ss SSHStrategy) Inspection() (*Inspection, error) {
+	settings, err := ss.Settings()
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := newInspection("ssh", "SSH Strategy", ss.Data.Version)
+	if ss.Data.SyncPwd {
+		command := append([]string{"rsync"}, ss.SyncArgs(settings)...)
+		inspection.add("sync_command", command, fmt.Sprintf("  sync command: %s\n", strings.Join(command, " ")))
+	}
+	command := append([]string{"ssh"}, ss.GenerateArgs(settings, []string{})...)
+	inspection.add("final_command", command, fmt.Sprintf("  final command: %s\n", strings.Join(command, " ")))
+
+	return inspection, nil
+}
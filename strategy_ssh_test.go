@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSSHStrategy() (*TestUtils, *SSHStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+
+	return tu, &SSHStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: SSHData{
+			Name:       "testssh",
+			Desc:       "Test SSH Program",
+			Version:    "1.4",
+			Host:       "build.example.com",
+			Command:    "/opt/testssh-{{.Version}}/bin/testssh",
+			Stdin:      true,
+			OSArchData: map[string]map[string]string{},
+		},
+	}
+}
+
+func TestSSHRun(t *testing.T) {
+	assert := assert.New(t)
+
+	var sshTests = []struct {
+		adjustment func(*TestUtils, *SSHStrategy)
+		history    []string
+	}{
+		{
+			nil,
+			[]string{"ssh -- build.example.com /opt/testssh-1.4/bin/testssh first 'second arg'"},
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				ts.Data.User = "builder"
+				ts.Data.Port = "2222"
+				ts.Data.Identity = "~/.ssh/build_{{.Version}}"
+				ts.Data.Terminal = "always"
+				ts.Data.Stdin = false
+			},
+			[]string{"ssh -p 2222 -i ~/.ssh/build_1.4 -t -n -- builder@build.example.com /opt/testssh-1.4/bin/testssh first 'second arg'"},
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				ts.Data.Terminal = "never"
+				tu.MemConfig.Set(false, "ssh.host", "shared.example.com")
+				tu.MemConfig.Set(false, "ssh.testssh.host", "local.example.com")
+				tu.MemConfig.Set(false, "ssh.user", "me")
+			},
+			[]string{"ssh -T -- me@local.example.com /opt/testssh-1.4/bin/testssh first 'second arg'"},
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				tu.MemConfig.Set(false, "ssh.testssh.command", "/usr/local/bin/testssh")
+			},
+			[]string{"ssh -- build.example.com /usr/local/bin/testssh first 'second arg'"},
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				ts.Data.Port = "2222"
+				ts.Data.SyncPwd = true
+				tu.MemConfig.Set(false, "ssh.host", "build.example.com")
+			},
+			[]string{
+				"rsync -az --delete -e ssh -p 2222 --rsync-path mkdir -p .holen/sync/testssh && rsync -- ./ build.example.com:.holen/sync/testssh/",
+				"ssh -p 2222 -- build.example.com cd .holen/sync/testssh && /opt/testssh-1.4/bin/testssh first 'second arg'",
+			},
+		},
+	}
+
+	for _, test := range sshTests {
+		tu, ts := newSSHStrategy()
+		if test.adjustment != nil {
+			test.adjustment(tu, ts)
+		}
+
+		assert.Nil(ts.Run([]string{"first", "second arg"}))
+		assert.Equal(test.history, tu.MemRunner.History)
+	}
+}
+
+func TestSSHSkip(t *testing.T) {
+	assert := assert.New(t)
+
+	var skipTests = []struct {
+		adjustment func(*TestUtils, *SSHStrategy)
+		err        error
+	}{
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				ts.Data.Host = ""
+			},
+			&SkipError{"no host configured, set ssh.testssh.host"},
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				tu.MemRunner.FailCheck("ssh -V")
+			},
+			&SkipError{"ssh not available"},
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				ts.Data.SyncPwd = true
+				tu.MemRunner.FailCheck("rsync --version")
+			},
+			&SkipError{"rsync not available"},
+		},
+	}
+
+	for _, test := range skipTests {
+		tu, ts := newSSHStrategy()
+		test.adjustment(tu, ts)
+
+		assert.Equal(test.err, ts.Run([]string{}))
+		assert.Empty(tu.MemRunner.History)
+	}
+}
+
+func TestSSHSyncFailed(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, ts := newSSHStrategy()
+	ts.Data.SyncPwd = true
+	tu.MemConfig.Set(false, "ssh.host", "build.example.com")
+	tu.MemRunner.FailCommand("rsync -az --delete -e ssh --rsync-path mkdir -p .holen/sync/testssh && rsync -- ./ build.example.com:.holen/sync/testssh/", fmt.Errorf("connection refused"))
+
+	err := ts.Run([]string{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "unable to sync current directory")
+	assert.Len(tu.MemRunner.History, 1)
+}
+
+func TestSSHSyncApproval(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		adjustment func(*TestUtils, *SSHStrategy)
+		prompts    int
+		history    int
+		err        string
+	}{
+		// the user approves syncing to the manifest's host
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				tu.MemSystem.ConfirmAnswer = true
+			},
+			1,
+			2,
+			"",
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {},
+			1,
+			0,
+			"privileged options for testssh 1.4 refused",
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				tu.MemConfig.SystemConfig = map[string]string{"privileged.forbid": "sync_pwd"}
+			},
+			0,
+			0,
+			"privileged option sync_pwd forbidden",
+		},
+		// a host chosen in config doesn't need approval
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				tu.MemConfig.Set(false, "ssh.testssh.host", "local.example.com")
+			},
+			0,
+			2,
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		tempdir, _ := ioutil.TempDir("", "holen")
+		defer os.RemoveAll(tempdir)
+
+		tu, ts := newSSHStrategy()
+		tu.MemSystem.Setenv("HOME", tempdir)
+		ts.Data.User = "builder"
+		ts.Data.SyncPwd = true
+		test.adjustment(tu, ts)
+
+		err := ts.Run([]string{})
+		if len(test.err) > 0 {
+			assert.EqualError(err, test.err)
+		} else {
+			assert.Nil(err)
+		}
+		assert.Len(tu.MemSystem.Prompts, test.prompts)
+		assert.Len(tu.MemRunner.History, test.history)
+		if test.prompts > 0 {
+			assert.Contains(tu.MemSystem.Prompts[0], "(builder@build.example.com)")
+		}
+	}
+}
+
+func TestSSHInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, ts := newSSHStrategy()
+	ts.Data.User = "builder"
+	assert.Nil(ts.Inspect())
+
+	assert.Equal([]string{
+		"SSH Strategy (version: 1.4):\n",
+		"  final command: ssh -- builder@build.example.com /opt/testssh-1.4/bin/testssh\n",
+	}, tu.MemSystem.StdoutMessages)
+}
+
+func TestSSHInvalidSettings(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		adjustment func(*TestUtils, *SSHStrategy)
+		err        string
+	}{
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				ts.Data.Host = "-oProxyCommand=touch /tmp/pwned"
+			},
+			"invalid ssh host -oProxyCommand=touch /tmp/pwned for testssh, it can't start with -",
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				ts.Data.User = "-oProxyCommand=id"
+			},
+			"invalid ssh user -oProxyCommand=id for testssh, it can't start with -",
+		},
+		{
+			func(tu *TestUtils, ts *SSHStrategy) {
+				tu.MemConfig.Set(false, "ssh.port", "22 -oProxyCommand=id")
+			},
+			"invalid ssh port 22 -oProxyCommand=id for testssh",
+		},
+	}
+
+	for _, test := range tests {
+		tu, ts := newSSHStrategy()
+		test.adjustment(tu, ts)
+
+		assert.EqualError(ts.Run([]string{}), test.err)
+		assert.Empty(tu.MemRunner.History)
+	}
+}
+
+func TestSSHSyncArgsQuoted(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ts := newSSHStrategy()
+	ts.Data.Port = "2222"
+	ts.Data.Identity = "/home/me/My Keys/build"
+	settings, err := ts.Settings()
+	assert.Nil(err)
+
+	assert.Equal([]string{
+		"-az", "--delete",
+		"-e", "ssh -p 2222 -i '/home/me/My Keys/build'",
+		"--rsync-path", "mkdir -p .holen/sync/testssh && rsync",
+		"--",
+		"./",
+		"build.example.com:.holen/sync/testssh/",
+	}, ts.SyncArgs(settings))
+}
+
+// execCapture runs commands for real, but captures the output of the one
+// that would be exec'd instead of replacing the test process.
+type execCapture struct {
+	DefaultRunner
+	output string
+}
+
+func (ec *execCapture) ExecCommand(command string, args []string) error {
+	output, err := ec.CommandOutput(command, args)
+	ec.output = output
+	return err
+}
+
+// TestSSHLocalSshd runs against a real sshd, which has to accept key based
+// logins without asking anything.  It's skipped unless HOLEN_TEST_SSH_HOST is
+// set, with HOLEN_TEST_SSH_USER and HOLEN_TEST_SSH_PORT optional.
+func TestSSHLocalSshd(t *testing.T) {
+	host := os.Getenv("HOLEN_TEST_SSH_HOST")
+	if len(host) == 0 {
+		t.Skip("set HOLEN_TEST_SSH_HOST to run against a real sshd")
+	}
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	ioutil.WriteFile(path.Join(tempdir, "hello.txt"), []byte("hello over ssh"), 0644)
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(tempdir)
+
+	runner := &execCapture{DefaultRunner: DefaultRunner{&MemLogger{}}}
+	tu, ts := newSSHStrategy()
+	ts.Runner = runner
+	tu.MemConfig.Set(false, "ssh.host", host)
+	tu.MemConfig.Set(false, "ssh.user", os.Getenv("HOLEN_TEST_SSH_USER"))
+	tu.MemConfig.Set(false, "ssh.port", os.Getenv("HOLEN_TEST_SSH_PORT"))
+	ts.Data.Command = "cat"
+	ts.Data.Stdin = false
+	ts.Data.SyncPwd = true
+	ts.Data.RemoteDir = fmt.Sprintf(".holen/sync/testssh-%d", os.Getpid())
+
+	assert.Nil(ts.Run([]string{"hello.txt"}))
+	assert.Equal("hello over ssh", runner.output)
+
+	settings, _ := ts.Settings()
+	runner.ExecCommand("ssh", append(ts.connectionArgs(settings), "-n", "--", ts.destination(settings), "rm -rf "+shellQuote(settings["remote_dir"])))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+)
+
+const defaultVersionRegex = `(\d+(\.\d+)+)`
+
+type SystemData struct {
+	Name           string
+	Desc           string
+	Version        string `yaml:"version"`
+	Executable     string `yaml:"executable"`
+	VersionCommand string `yaml:"version_command"`
+	VersionRegex   string `yaml:"version_regex"`
+	Constraint     string `yaml:"constraint"`
+}
+
+// SystemStrategy runs a copy of the utility that's already installed on the
+// host, as long as it's the right version.
+type SystemStrategy struct {
+	*StrategyCommon
+	Data SystemData
+}
+
+func (ss SystemStrategy) Version() string {
+	return ss.Data.Version
+}
+
+func (ss SystemStrategy) executable() string {
+	executable := ss.Data.Executable
+	if len(executable) == 0 {
+		executable = ss.Data.Name
+	}
+	if ss.OS() == "windows" && !strings.HasSuffix(executable, ".exe") {
+		executable += ".exe"
+	}
+	return executable
+}
+
+// FindExecutable looks for the executable on the PATH, passing over any
+// links that point back to holen itself.
+func (ss SystemStrategy) FindExecutable() (string, error) {
+	executable := ss.executable()
+
+	for _, candidate := range lookPath(ss.Getenv("PATH"), executable) {
+		if isHolenLink(candidate) {
+			ss.Debugf("skipping holen link %s", candidate)
+			continue
+		}
+
+		return candidate, nil
+	}
+
+	return "", &SkipError{fmt.Sprintf("%s not found on PATH", executable)}
+}
+
+// InstalledVersion runs the version command and pulls the version out of
+// its output.
+func (ss SystemStrategy) InstalledVersion(localPath string) (string, error) {
+	versionCommand := ss.Data.VersionCommand
+	if len(versionCommand) == 0 {
+		versionCommand = "--version"
+	}
+
+	versionRegex := ss.Data.VersionRegex
+	if len(versionRegex) == 0 {
+		versionRegex = defaultVersionRegex
+	}
+	re, err := regexp.Compile(versionRegex)
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("invalid version_regex %s", versionRegex))
+	}
+
+	output, err := ss.CommandOutput(localPath, strings.Fields(versionCommand))
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("unable to get version of %s", localPath))
+	}
+
+	matches := re.FindStringSubmatch(output)
+	if matches == nil {
+		return "", fmt.Errorf("no version found in output of %s %s", localPath, versionCommand)
+	}
+	if len(matches) > 1 {
+		return matches[1], nil
+	}
+	return matches[0], nil
+}
+
+// Satisfied checks the installed version against the constraint, if there
+// is one, or else the version being asked for.
+func (ss SystemStrategy) Satisfied(installed string) (bool, error) {
+	installedVersion, err := goversion.NewVersion(installed)
+	if err != nil {
+		return installed == ss.Data.Version, nil
+	}
+
+	if len(ss.Data.Constraint) > 0 {
+		constraint, err := goversion.NewConstraint(ss.Data.Constraint)
+		if err != nil {
+			return false, errors.Wrap(err, fmt.Sprintf("invalid constraint %s", ss.Data.Constraint))
+		}
+		return constraint.Check(installedVersion), nil
+	}
+
+	wantVersion, err := goversion.NewVersion(ss.Data.Version)
+	if err != nil {
+		return installed == ss.Data.Version, nil
+	}
+
+	return installedVersion.Equal(wantVersion), nil
+}
+
+func (ss SystemStrategy) Run(args []string) error {
+	localPath, err := ss.FindExecutable()
+	if err != nil {
+		return err
+	}
+
+	installed, err := ss.InstalledVersion(localPath)
+	if err != nil {
+		ss.Debugf("%s", err)
+		return &SkipError{fmt.Sprintf("unable to determine version of %s", localPath)}
+	}
+
+	ok, err := ss.Satisfied(installed)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &SkipError{fmt.Sprintf("%s is version %s, not %s", localPath, installed, ss.wanted())}
+	}
+
+	ss.RecordUsage(ss.Data.Name, ss.Data.Version, "", "")
+	err = ss.ExecCommand(localPath, args)
+	if err != nil {
+		return errors.Wrap(err, "can't run binary")
+	}
+
+	return nil
+}
+
+func (ss SystemStrategy) wanted() string {
+	if len(ss.Data.Constraint) > 0 {
+		return ss.Data.Constraint
+	}
+	return ss.Data.Version
+}
+
+func (ss SystemStrategy) Inspect() error {
+	return ss.showInspection(ss.Inspection())
+}
+
+func (ss SystemStrategy) Inspection() (*Inspection, error) {
+	inspection := newInspection("system", "System Strategy", ss.Data.Version)
+	inspection.Add("executable", "executable", ss.executable())
+	inspection.Add("wanted_version", "wanted version", ss.wanted())
+
+	localPath, err := ss.FindExecutable()
+	if err != nil {
+		inspection.add("found", nil, "  found: none\n")
+		return inspection, nil
+	}
+
+	installed, err := ss.InstalledVersion(localPath)
+	if err != nil {
+		installed = "unknown"
+	}
+	inspection.add("found", map[string]string{"path": localPath, "version": installed},
+		fmt.Sprintf("  found: %s (version %s)\n", localPath, installed))
+
+	return inspection, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSystemStrategy() (*TestUtils, *SystemStrategy) {
+	tu := &TestUtils{
+		MemSystem:     NewMemSystem(),
+		MemLogger:     &MemLogger{},
+		MemConfig:     NewMemConfig(),
+		MemDownloader: &MemDownloader{},
+		MemRunner:     &MemRunner{},
+	}
+	tu.MemSystem.MOS = "linux"
+
+	return tu, &SystemStrategy{
+		StrategyCommon: &StrategyCommon{
+			System:       tu.MemSystem,
+			Logger:       tu.MemLogger,
+			ConfigGetter: tu.MemConfig,
+			Downloader:   tu.MemDownloader,
+			Runner:       tu.MemRunner,
+		},
+		Data: SystemData{
+			Name:    "jq",
+			Desc:    "Test System Program",
+			Version: "1.6",
+		},
+	}
+}
+
+// makeTestPath creates a directory with a holen link and one with a real
+// executable, returning a PATH with the link first.
+func makeTestPath(tempdir string) (string, string) {
+	linkDir := path.Join(tempdir, "links")
+	binDir := path.Join(tempdir, "bin")
+	os.MkdirAll(linkDir, 0755)
+	os.MkdirAll(binDir, 0755)
+
+	os.Symlink("/usr/local/bin/holen", path.Join(linkDir, "jq"))
+	ioutil.WriteFile(path.Join(binDir, "jq"), []byte("#!/bin/sh\n"), 0755)
+	ioutil.WriteFile(path.Join(binDir, "notexec"), []byte("#!/bin/sh\n"), 0644)
+
+	return strings.Join([]string{linkDir, binDir}, string(os.PathListSeparator)), path.Join(binDir, "jq")
+}
+
+func TestSystemRun(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	pathEnv, jqPath := makeTestPath(tempdir)
+
+	var systemTests = []struct {
+		adjustment func(*TestUtils, *SystemStrategy)
+		err        error
+	}{
+		{
+			func(tu *TestUtils, ts *SystemStrategy) {
+				tu.MemRunner.SetOutput(jqPath+" --version", "jq-1.6")
+			},
+			nil,
+		},
+		{
+			func(tu *TestUtils, ts *SystemStrategy) {
+				tu.MemRunner.SetOutput(jqPath+" --version", "jq-1.5")
+			},
+			&SkipError{fmt.Sprintf("%s is version 1.5, not 1.6", jqPath)},
+		},
+		{
+			func(tu *TestUtils, ts *SystemStrategy) {
+				ts.Data.Constraint = ">= 1.5, < 2"
+				tu.MemRunner.SetOutput(jqPath+" --version", "jq-1.5.1")
+			},
+			nil,
+		},
+		{
+			func(tu *TestUtils, ts *SystemStrategy) {
+				ts.Data.Constraint = ">= 1.6"
+				tu.MemRunner.SetOutput(jqPath+" --version", "jq-1.5.1")
+			},
+			&SkipError{fmt.Sprintf("%s is version 1.5.1, not >= 1.6", jqPath)},
+		},
+		{
+			func(tu *TestUtils, ts *SystemStrategy) {
+				ts.Data.VersionCommand = "version --short"
+				ts.Data.VersionRegex = `v(\d+\.\d+) \(`
+				tu.MemRunner.SetOutput(jqPath+" version --short", "jq v1.6 (build 2.1)")
+			},
+			nil,
+		},
+		{
+			func(tu *TestUtils, ts *SystemStrategy) {
+				tu.MemRunner.SetOutput(jqPath+" --version", "unknown")
+			},
+			&SkipError{fmt.Sprintf("unable to determine version of %s", jqPath)},
+		},
+		{
+			func(tu *TestUtils, ts *SystemStrategy) {
+				ts.Data.Executable = "notexec"
+			},
+			&SkipError{"notexec not found on PATH"},
+		},
+		{
+			func(tu *TestUtils, ts *SystemStrategy) {
+				tu.MemSystem.Setenv("PATH", path.Join(tempdir, "links"))
+			},
+			&SkipError{"jq not found on PATH"},
+		},
+	}
+
+	for _, test := range systemTests {
+		dataDir, _ := ioutil.TempDir("", "holen")
+		defer os.RemoveAll(dataDir)
+
+		tu, ts := newSystemStrategy()
+		tu.MemSystem.Setenv("PATH", pathEnv)
+		tu.MemSystem.Setenv("XDG_DATA_HOME", dataDir)
+		test.adjustment(tu, ts)
+
+		err := ts.Run([]string{"-r", "."})
+		assert.Equal(test.err, err)
+
+		// usage is only recorded for the strategy that runs
+		_, lastUsed := readUsage(tu.MemSystem, "jq", "1.6")
+		if test.err == nil {
+			assert.Equal([]string{jqPath + " -r ."}, tu.MemRunner.History)
+			assert.False(lastUsed.IsZero())
+		} else {
+			assert.Empty(tu.MemRunner.History)
+			assert.True(lastUsed.IsZero())
+		}
+	}
+}
+
+func TestSystemInspect(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	pathEnv, jqPath := makeTestPath(tempdir)
+
+	tu, ts := newSystemStrategy()
+	tu.MemSystem.Setenv("PATH", pathEnv)
+	tu.MemRunner.SetOutput(jqPath+" --version", "jq-1.5")
+	assert.Nil(ts.Inspect())
+
+	assert.Equal([]string{
+		"System Strategy (version: 1.6):\n",
+		"  executable: jq\n",
+		"  wanted version: 1.6\n",
+		fmt.Sprintf("  found: %s (version 1.5)\n", jqPath),
+	}, tu.MemSystem.StdoutMessages)
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -58,10 +59,16 @@ func TestDockerSimple(t *testing.T) {
 func TestDockerAllOptions(t *testing.T) {
 	assert := assert.New(t)
 
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
 	tu, td := newDockerStrategy()
+	tu.MemSystem.Setenv("HOME", tempdir)
+	tu.MemSystem.ConfirmAnswer = true
 	td.Data.Interactive = true
 	td.Data.DockerConn = true
 	td.Data.PidHost = true
+	td.Data.Privileged = true
 	td.Data.MountPwdAs = "/test"
 	td.Data.MountPwd = true
 	td.Data.RunAsUser = true
@@ -70,7 +77,167 @@ func TestDockerAllOptions(t *testing.T) {
 	assert.Nil(td.Run([]string{"first", "second"}))
 
 	wd, _ := os.Getwd()
-	assert.Equal(tu.MemRunner.History[0], fmt.Sprintf("docker run -i -v /var/run/docker.sock:/var/run/docker.sock --pid host --volume %s:/test --workdir /test --volume %s:%s --workdir %s -u 1000:1000 -t --rm testdocker:1.9 first second", wd, wd, wd, wd))
+	assert.Len(tu.MemSystem.Prompts, 1)
+	assert.Equal(tu.MemRunner.History[0], fmt.Sprintf("docker run -i -v /var/run/docker.sock:/var/run/docker.sock --pid host --privileged --volume %s:/test --workdir /test --volume %s:%s --workdir %s -u 1000:1000 -t --rm testdocker:1.9 first second", wd, wd, wd, wd))
+}
+
+func TestDockerRunOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, td := newDockerStrategy()
+	tu.MemSystem.Setenv("HOME", tempdir)
+	tu.MemSystem.ConfirmAnswer = true
+	td.Data.Ports = []string{"8888", "{{.Version}}0:80", "127.0.0.1:53:53/udp"}
+	td.Data.Network = "testnet"
+	td.Data.Entrypoint = "/bin/sh"
+	td.Data.Platform = "linux/amd64"
+	td.Data.ExtraArgs = []string{"--init"}
+	td.Data.UserArgs = []string{"-e", "DEBUG=1"}
+	tu.MemConfig.UserConfig = map[string]string{"docker.testdocker.port.8888": "9999"}
+	assert.Nil(td.Run([]string{"first", "second"}))
+
+	// extra_args from the manifest need approval
+	assert.Len(tu.MemSystem.Prompts, 1)
+	assert.Equal("docker run -p 9999:8888 -p 1.90:80 -p 127.0.0.1:53:53/udp --network testnet --entrypoint /bin/sh --platform linux/amd64 --init -e DEBUG=1 --rm testdocker:1.9 first second", tu.MemRunner.History[0])
+}
+
+func TestDockerPlatforms(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		arch      string
+		platforms []string
+		config    map[string]string
+		command   string
+		skip      bool
+	}{
+		// no platforms declared, leave it to the runtime
+		{
+			"arm64",
+			[]string{},
+			map[string]string{},
+			"docker run --rm testdocker:1.9 first",
+			false,
+		},
+		// native platform available
+		{
+			"arm64",
+			[]string{"linux/amd64", "linux/arm64"},
+			map[string]string{},
+			"docker run --rm testdocker:1.9 first",
+			false,
+		},
+		{
+			"arm",
+			[]string{"linux/arm/v7"},
+			map[string]string{},
+			"docker run --rm testdocker:1.9 first",
+			false,
+		},
+		// not supported, fall back to another strategy
+		{
+			"arm64",
+			[]string{"linux/amd64"},
+			map[string]string{},
+			"",
+			true,
+		},
+		// emulation opted in
+		{
+			"arm64",
+			[]string{"linux/386", "linux/amd64"},
+			map[string]string{"docker.emulate": "true"},
+			"docker run --platform linux/amd64 --rm testdocker:1.9 first",
+			false,
+		},
+		{
+			"arm64",
+			[]string{"linux/386"},
+			map[string]string{"docker.testdocker.emulate": "true"},
+			"docker run --platform linux/386 --rm testdocker:1.9 first",
+			false,
+		},
+		{
+			"arm64",
+			[]string{"linux/amd64"},
+			map[string]string{"docker.emulate": "true", "docker.testdocker.emulate": "false"},
+			"",
+			true,
+		},
+	}
+
+	for _, test := range tests {
+		tu, td := newDockerStrategy()
+		tu.MemSystem.MArch = test.arch
+		tu.MemConfig.UserConfig = test.config
+		td.Data.Platforms = test.platforms
+
+		err := td.Run([]string{"first"})
+		if test.skip {
+			assert.IsType(&SkipError{}, err)
+			assert.Len(tu.MemRunner.History, 0)
+		} else {
+			assert.Nil(err)
+			assert.Equal(test.command, tu.MemRunner.History[0])
+		}
+	}
+}
+
+func TestDockerPrivilegedOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		modify  func(*DockerStrategy)
+		options []string
+	}{
+		{
+			func(td *DockerStrategy) {},
+			[]string{},
+		},
+		{
+			func(td *DockerStrategy) {
+				td.Data.DockerConn = true
+				td.Data.Network = "host"
+			},
+			[]string{"docker_conn", "network_host"},
+		},
+		{
+			func(td *DockerStrategy) {
+				td.Data.PidHost = true
+				td.Data.ExtraArgs = []string{"--pid=host", "--privileged", "--net", "host", "-v", "/var/run/docker.sock:/sock"}
+			},
+			[]string{"pid_host", "privileged", "network_host", "docker_conn", "extra_args: --pid=host --privileged --net host -v /var/run/docker.sock:/sock"},
+		},
+		{
+			func(td *DockerStrategy) {
+				td.Data.ExtraArgs = []string{"-v", "/:/host", "--cap-add=ALL"}
+			},
+			[]string{"extra_args: -v /:/host --cap-add=ALL"},
+		},
+		// flags given on the command line are the user's own choice
+		{
+			func(td *DockerStrategy) {
+				td.Data.UserArgs = []string{"--privileged"}
+			},
+			[]string{},
+		},
+		{
+			func(td *DockerStrategy) {
+				td.Data.BootstrapScript = "/bootstrap"
+			},
+			[]string{"bootstrap_script"},
+		},
+	}
+
+	for _, test := range tests {
+		_, td := newDockerStrategy()
+		test.modify(td)
+
+		assert.Equal(test.options, td.PrivilegedOptions())
+	}
 }
 
 func TestDockerNotInstalled(t *testing.T) {
@@ -78,9 +245,97 @@ func TestDockerNotInstalled(t *testing.T) {
 
 	tu, td := newDockerStrategy()
 	tu.MemRunner.FailCheck("docker version")
+	tu.MemRunner.FailCheck("podman version")
+	tu.MemRunner.FailCheck("nerdctl version")
 	err := td.Run([]string{"first", "second"})
 	assert.NotNil(err)
-	assert.Contains(err.Error(), "docker not available")
+	assert.IsType(&SkipError{}, err)
+	assert.Contains(err.Error(), "no container runtime available")
+}
+
+func TestDockerRuntime(t *testing.T) {
+	assert := assert.New(t)
+
+	var tests = []struct {
+		modify  func(*TestUtils)
+		runtime string
+		err     string
+	}{
+		{
+			func(tu *TestUtils) {},
+			"docker",
+			"",
+		},
+		{
+			func(tu *TestUtils) {
+				tu.MemRunner.FailCheck("docker version")
+			},
+			"podman",
+			"",
+		},
+		{
+			func(tu *TestUtils) {
+				tu.MemRunner.FailCheck("docker version")
+				tu.MemRunner.FailCheck("podman version")
+			},
+			"nerdctl",
+			"",
+		},
+		{
+			func(tu *TestUtils) {
+				tu.MemConfig.UserConfig = map[string]string{"docker.runtime": "nerdctl"}
+			},
+			"nerdctl",
+			"",
+		},
+		{
+			func(tu *TestUtils) {
+				tu.MemConfig.UserConfig = map[string]string{"docker.runtime": "podman"}
+				tu.MemRunner.FailCheck("podman version")
+			},
+			"",
+			"podman not available",
+		},
+		{
+			func(tu *TestUtils) {
+				tu.MemConfig.UserConfig = map[string]string{"docker.runtime": "rkt"}
+			},
+			"",
+			"unsupported container runtime rkt",
+		},
+	}
+
+	for _, test := range tests {
+		tu, td := newDockerStrategy()
+		test.modify(tu)
+
+		containerRuntime, err := td.Runtime()
+		if len(test.err) > 0 {
+			assert.NotNil(err)
+			assert.Contains(err.Error(), test.err)
+		} else {
+			assert.Nil(err)
+			assert.Equal(test.runtime, containerRuntime)
+		}
+	}
+}
+
+func TestDockerPodmanOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, td := newDockerStrategy()
+	tu.MemConfig.UserConfig = map[string]string{"docker.runtime": "podman"}
+	tu.MemSystem.Setenv("HOME", tempdir)
+	tu.MemSystem.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+	tu.MemSystem.ConfirmAnswer = true
+	td.Data.DockerConn = true
+	td.Data.RunAsUser = true
+	assert.Nil(td.Run([]string{"first", "second"}))
+
+	assert.Equal("podman run -v /run/user/1000/podman/podman.sock:/var/run/docker.sock --userns=keep-id --rm testdocker:1.9 first second", tu.MemRunner.History[0])
 }
 
 func TestDockerBadImageTemplate(t *testing.T) {
@@ -107,47 +362,198 @@ func TestDockerCommandFailed(t *testing.T) {
 func TestDockerBootstrapScript(t *testing.T) {
 	assert := assert.New(t)
 
+	inspectCommand := "docker image inspect --format {{.Id}} testdocker:1.9"
+	extractCommand := "docker run --rm -i testdocker:1.9 cat /bootstrap"
+
 	var tests = []struct {
-		modify func(*TestUtils, *DockerStrategy)
-		err    error
+		modify  func(*TestUtils, *DockerStrategy)
+		err     error
+		extract bool
+		env     string
 	}{
 		{
 			func(tu *TestUtils, td *DockerStrategy) {},
 			nil,
+			true,
+			"DOCKER_IMAGE=testdocker:1.9",
 		},
 		{
 			func(tu *TestUtils, td *DockerStrategy) {
-				tu.MemRunner.FailCommand("docker run --rm -i testdocker:1.9 cat /bootstrap", fmt.Errorf("fail"))
+				td.Data.BootstrapEnv = "TEST_IMAGE"
+			},
+			nil,
+			true,
+			"TEST_IMAGE=testdocker:1.9",
+		},
+		{
+			func(tu *TestUtils, td *DockerStrategy) {
+				dataPath, _ := tu.MemSystem.DataPath()
+				tu.MemSystem.Files[path.Join(dataPath, "bootstrap", "sha256-abcd", "bootstrap")] = true
+			},
+			nil,
+			false,
+			"DOCKER_IMAGE=testdocker:1.9",
+		},
+		{
+			func(tu *TestUtils, td *DockerStrategy) {
+				tu.MemRunner.FailCommand(extractCommand, fmt.Errorf("fail"))
 			},
 			fmt.Errorf("fail"),
+			false,
+			"",
+		},
+		{
+			func(tu *TestUtils, td *DockerStrategy) {
+				tu.MemRunner.SetOutput(inspectCommand, "")
+			},
+			fmt.Errorf("no digest found"),
+			false,
+			"",
+		},
+		// bootstrap scripts need approval, like any privileged option
+		{
+			func(tu *TestUtils, td *DockerStrategy) {
+				tu.MemSystem.ConfirmAnswer = false
+			},
+			fmt.Errorf("privileged options for testdocker 1.9 refused"),
+			false,
+			"",
+		},
+		{
+			func(tu *TestUtils, td *DockerStrategy) {
+				tu.MemConfig.SystemConfig = map[string]string{"privileged.forbid": "bootstrap_script"}
+			},
+			fmt.Errorf("privileged option bootstrap_script forbidden"),
+			false,
+			"",
 		},
 	}
 
 	for _, test := range tests {
+		tempdir, _ := ioutil.TempDir("", "holen")
+		defer os.RemoveAll(tempdir)
+
 		tu, td := newDockerStrategy()
-		test.modify(tu, td)
+		tu.MemSystem.Setenv("HOME", tempdir)
+		tu.MemRunner.SetOutput(inspectCommand, "sha256:abcd")
 		td.Data.BootstrapScript = "/bootstrap"
+		td.Data.BootstrapEnv = "DOCKER_IMAGE"
+		tu.MemSystem.ConfirmAnswer = true
+		test.modify(tu, td)
 
 		result := td.Run([]string{"first", "second"})
 
 		if test.err != nil {
 			assert.NotNil(result)
+			assert.Contains(result.Error(), test.err.Error())
 		} else {
 			assert.Nil(result)
-			assert.Contains(tu.MemRunner.CommandOutputCmds, "docker run --rm -i testdocker:1.9 cat /bootstrap")
+			scriptPath := path.Join(tempdir, ".local/share/holen/bootstrap/sha256-abcd/bootstrap")
+
+			if test.extract {
+				assert.Contains(tu.MemRunner.CommandOutputCmds, extractCommand)
+				assert.Equal(fmt.Sprintf("%s.tmp", scriptPath), tu.MemRunner.CommandOutputCmds[extractCommand])
+			} else {
+				assert.NotContains(tu.MemRunner.CommandOutputCmds, extractCommand)
+			}
 
 			// check env vars for actual run
 			var envs [][]string
 			for _, v := range tu.MemRunner.HistoryEnv {
 				envs = append(envs, v)
 			}
-			assert.Equal([]string{"DOCKER_IMAGE=testdocker:1.9"}, envs[0])
+			assert.Equal([]string{test.env}, envs[0])
 
-			assert.Contains(tu.MemRunner.History[0], "/execute first second")
+			assert.Equal(fmt.Sprintf("%s first second", scriptPath), tu.MemRunner.History[0])
 		}
 	}
 }
 
+func TestDockerBootstrapPlatform(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	tu, td := newDockerStrategy()
+	tu.MemSystem.Setenv("HOME", tempdir)
+	tu.MemSystem.MArch = "arm64"
+	tu.MemSystem.ConfirmAnswer = true
+	tu.MemConfig.UserConfig = map[string]string{"docker.emulate": "true"}
+	tu.MemRunner.SetOutput("docker image inspect --format {{.Id}} testdocker:1.9", "sha256:abcd")
+	td.Data.Platforms = []string{"linux/amd64"}
+	td.Data.BootstrapScript = "/bootstrap"
+	td.Data.BootstrapEnv = "DOCKER_IMAGE"
+
+	assert.Nil(td.Run([]string{"first"}))
+
+	// the script is extracted for the emulated platform and told which
+	// platform to run
+	assert.Contains(tu.MemRunner.CommandOutputCmds, "docker run --rm -i --platform linux/amd64 testdocker:1.9 cat /bootstrap")
+	assert.Len(tu.MemRunner.HistoryEnv, 1)
+	for _, env := range tu.MemRunner.HistoryEnv {
+		assert.Equal([]string{"DOCKER_IMAGE=testdocker:1.9", "HOLEN_DOCKER_PLATFORM=linux/amd64"}, env)
+	}
+
+	// a missing image is pulled for that platform too
+	tu.MemRunner.FailCommand("docker image inspect --format {{.Id}} testdocker:1.9", fmt.Errorf("no such image"))
+	td.Data.Platform = "linux/amd64"
+	td.ImageDigest("docker", "testdocker:1.9")
+	assert.Contains(tu.MemRunner.History, "docker pull --platform linux/amd64 testdocker:1.9")
+}
+
+func TestDockerInstallPlatform(t *testing.T) {
+	assert := assert.New(t)
+
+	// no platforms declared, the image is pulled as is
+	tu, td := newDockerStrategy()
+	image, err := td.Install()
+	assert.Nil(err)
+	assert.Equal("testdocker:1.9", image)
+	assert.Equal([]string{"testdocker:1.9"}, tu.MemDownloader.DockerImages)
+
+	// an emulated platform is pulled explicitly
+	tu, td = newDockerStrategy()
+	tu.MemSystem.MArch = "arm64"
+	tu.MemConfig.UserConfig = map[string]string{"docker.emulate": "true"}
+	td.Data.Platforms = []string{"linux/amd64"}
+	_, err = td.Install()
+	assert.Nil(err)
+	assert.Contains(tu.MemRunner.History, "docker pull --platform linux/amd64 testdocker:1.9")
+	assert.Empty(tu.MemDownloader.DockerImages)
+
+	// nothing is pulled when no platform can run here
+	tu, td = newDockerStrategy()
+	tu.MemSystem.MArch = "arm64"
+	td.Data.Platforms = []string{"linux/amd64"}
+	_, err = td.Install()
+	assert.IsType(&SkipError{}, err)
+	assert.Empty(tu.MemRunner.History)
+	assert.Empty(tu.MemDownloader.DockerImages)
+}
+
+func TestDockerCleanBootstrapCache(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "bootstrap")
+	defer os.RemoveAll(tempdir)
+
+	old := time.Now().Add(-24 * time.Hour)
+	for _, name := range []string{"sha256-old", "sha256-new"} {
+		os.MkdirAll(path.Join(tempdir, name), 0755)
+	}
+	os.Chtimes(path.Join(tempdir, "sha256-old"), old, old)
+
+	tu, td := newDockerStrategy()
+	tu.MemConfig.SystemConfig = map[string]string{"docker.bootstrap_max_age": "1h"}
+	assert.Nil(td.CleanBootstrapCache(tempdir))
+
+	_, err := os.Stat(path.Join(tempdir, "sha256-old"))
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(path.Join(tempdir, "sha256-new"))
+	assert.Nil(err)
+}
+
 func TestDockerInspect(t *testing.T) {
 	assert := assert.New(t)
 
@@ -246,6 +652,137 @@ func TestBinaryArchive(t *testing.T) {
 	assert.Contains(tu.MemSystem.StderrMessages[0], remoteUrl)
 
 	assert.Equal(tu.MemRunner.History[0], fmt.Sprintf("%s first second", binPath))
+	assert.Equal(ArchiveOptions{Format: "zip", MaxSize: defaultMaxArchiveSize}, tu.MemSystem.UnpackOptions["testbinary-linux_amd64.zip"])
+}
+
+func TestBinaryArchiveOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tb := newBinaryStrategy()
+	tb.Data.UnpackPath = "testbinary"
+	tb.Data.BaseURL = "https://github.com/testbinary/bin/releases/download/bin-{{.Version}}/testbinary-{{.OSArch}}"
+	tb.Data.ArchiveFormat = "tar.zst"
+	tb.Data.StripComponents = 1
+	tu.MemSystem.ArchiveFiles["testbinary-linux_amd64"] = []string{"testbinary"}
+	tu.MemConfig.UserConfig = map[string]string{"archive.max_size": "100M"}
+
+	assert.Nil(tb.Run([]string{}))
+	assert.Equal(ArchiveOptions{Format: "tar.zst", StripComponents: 1, MaxSize: 100 << 20}, tu.MemSystem.UnpackOptions["testbinary-linux_amd64"])
+
+	tu, tb = newBinaryStrategy()
+	tb.Data.UnpackPath = "testbinary"
+	tb.Data.BaseURL = "https://github.com/testbinary/bin/releases/download/bin-{{.Version}}/testbinary-{{.OSArch}}"
+
+	err := tb.Run([]string{})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "unable to detect archive format of testbinary-linux_amd64")
+	assert.Empty(tu.MemRunner.History)
+}
+
+func TestBinaryProvides(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	pkgPath := path.Join(tempdir, "holen/pkgs/testbinary/2.1")
+
+	var providesTests = []struct {
+		adjustment func(*TestUtils, *BinaryStrategy)
+		err        error
+		history    []string
+		downloaded bool
+	}{
+		{
+			nil,
+			nil,
+			[]string{fmt.Sprintf("%s/testbinary first second", pkgPath)},
+			true,
+		},
+		{
+			func(tu *TestUtils, tb *BinaryStrategy) {
+				tb.Data.Command = "testbinary-gen"
+			},
+			nil,
+			[]string{fmt.Sprintf("%s/testbinary-gen-2.1 first second", pkgPath)},
+			true,
+		},
+		{
+			func(tu *TestUtils, tb *BinaryStrategy) {
+				tb.Data.Command = "testbinary-gen"
+				tu.MemSystem.Files[pkgPath] = true
+			},
+			nil,
+			[]string{fmt.Sprintf("%s/testbinary-gen-2.1 first second", pkgPath)},
+			false,
+		},
+		{
+			func(tu *TestUtils, tb *BinaryStrategy) {
+				tb.Data.Command = "testbinary-lint"
+			},
+			fmt.Errorf("testbinary 2.1 does not provide testbinary-lint"),
+			nil,
+			false,
+		},
+	}
+
+	for _, test := range providesTests {
+		os.RemoveAll(path.Join(tempdir, "holen"))
+
+		tu, tb := newBinaryStrategy()
+		tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+		tb.Data.BaseURL = "https://github.com/testbinary/bin/releases/download/bin-{{.Version}}/testbinary-{{.OSArch}}.tar.gz"
+		tb.Data.UnpackPath = "testbinary"
+		tb.Data.Provides = map[string]string{"testbinary-gen": "testbinary-gen-{{.Version}}"}
+		tb.Data.OSArchData = map[string]map[string]string{}
+		tu.MemSystem.ArchiveFiles["testbinary-linux_amd64.tar.gz"] = []string{"testbinary", "testbinary-gen-2.1"}
+		if test.adjustment != nil {
+			test.adjustment(tu, tb)
+		}
+
+		assert.Equal(test.err, tb.Run([]string{"first", "second"}))
+		assert.Equal(test.history, tu.MemRunner.History)
+		assert.Equal(test.downloaded, len(tu.MemDownloader.Files) > 0)
+		if test.downloaded {
+			_, err := os.Stat(path.Join(pkgPath, "testbinary-gen-2.1"))
+			assert.Nil(err)
+		}
+	}
+}
+
+func TestBinaryKeepTree(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	pkgPath := path.Join(tempdir, "holen/pkgs/testbinary/2.1")
+
+	tu, tb := newBinaryStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", tempdir)
+	tu.MemSystem.Setenv("PATH", "/usr/bin:/bin")
+	tb.Data.BaseURL = "https://github.com/testbinary/bin/releases/download/bin-{{.Version}}/testbinary-{{.OSArch}}.tar.gz"
+	tb.Data.UnpackPath = "testbinary"
+	tb.Data.KeepTree = true
+	tb.Data.Env = map[string]string{
+		"TESTBINARY_HOME": "{{.Root}}",
+		"PATH":            "{{.Root}}/bin:$PATH",
+	}
+	tb.Data.OSArchData = map[string]map[string]string{}
+	tu.MemSystem.ArchiveFiles["testbinary-linux_amd64.tar.gz"] = []string{"testbinary", "libtestbinary.so"}
+
+	assert.Nil(tb.Run([]string{"first", "second"}))
+
+	command := fmt.Sprintf("%s/testbinary first second", pkgPath)
+	assert.Equal([]string{command}, tu.MemRunner.History)
+	assert.Equal([]string{
+		fmt.Sprintf("PATH=%s/bin:/usr/bin:/bin", pkgPath),
+		fmt.Sprintf("TESTBINARY_HOME=%s", pkgPath),
+	}, tu.MemRunner.HistoryEnv[command])
+
+	_, err := os.Stat(path.Join(pkgPath, "libtestbinary.so"))
+	assert.Nil(err)
+
+	_, err = os.Stat(path.Join(tempdir, "holen/bin/testbinary--2.1"))
+	assert.True(os.IsNotExist(err))
 }
 
 func TestBinaryDownloadPath(t *testing.T) {
@@ -354,6 +891,34 @@ func TestBinaryInspect(t *testing.T) {
 	assert.Contains(completeOutput, "checksum with md5: d41d8cd98f00b204e9800998ecf8427e")
 }
 
+func TestBinaryInspectProvides(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tb := newBinaryStrategy()
+	tb.Data.Provides = map[string]string{
+		"testbinary-gen": "bin/testbinary-gen-{{.Version}}",
+		"testbinary":     "bin/testbinary",
+	}
+	tb.Inspect()
+	completeOutput := strings.Join(tu.MemSystem.StdoutMessages, "")
+
+	assert.Contains(completeOutput, "  provides:\n    testbinary: bin/testbinary\n    testbinary-gen: bin/testbinary-gen-2.1\n")
+}
+
+func TestBinaryInspectKeepTree(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tb := newBinaryStrategy()
+	tu.MemSystem.Setenv("XDG_DATA_HOME", "/tmp")
+	tb.Data.KeepTree = true
+	tb.Data.Env = map[string]string{"TESTBINARY_HOME": "{{.Root}}/share"}
+	tb.Inspect()
+	completeOutput := strings.Join(tu.MemSystem.StdoutMessages, "")
+
+	assert.Contains(completeOutput, "  package path: /tmp/holen/pkgs/testbinary/2.1\n")
+	assert.Contains(completeOutput, "  env: TESTBINARY_HOME=/tmp/holen/pkgs/testbinary/2.1/share\n")
+}
+
 func newCmdioStrategy() (*TestUtils, *CmdioStrategy) {
 	tu := &TestUtils{
 		MemSystem:     NewMemSystem(),
@@ -388,7 +953,19 @@ func TestCmdioSimple(t *testing.T) {
 	err := tc.Run([]string{"first", "second"})
 	assert.Nil(err)
 
-	assert.Equal(tu.MemRunner.History[0], "ssh alpha.cmd.io testbinary--2.1 first second")
+	assert.Equal(tu.MemRunner.History[0], "ssh -- alpha.cmd.io testbinary--2.1 first second")
+}
+
+func TestCmdioIgnoresSSHConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	tu, tc := newCmdioStrategy()
+	*tu.MemConfig = *NewMemConfig()
+	tu.MemConfig.Set(false, "ssh.host", "build.example.com")
+	tu.MemConfig.Set(false, "ssh.testbinary.user", "builder")
+	assert.Nil(tc.Run([]string{"first", "second"}))
+
+	assert.Equal([]string{"ssh -- alpha.cmd.io testbinary--2.1 first second"}, tu.MemRunner.History)
 }
 
 func TestCmdioInspect(t *testing.T) {
@@ -405,7 +982,7 @@ func TestCmdioCommandFailed(t *testing.T) {
 	assert := assert.New(t)
 
 	tu, tc := newCmdioStrategy()
-	tu.MemRunner.FailCommand("ssh alpha.cmd.io testbinary--2.1 first second", fmt.Errorf("bad output"))
+	tu.MemRunner.FailCommand("ssh -- alpha.cmd.io testbinary--2.1 first second", fmt.Errorf("bad output"))
 	err := tc.Run([]string{"first", "second"})
 	assert.NotNil(err)
 	assert.Contains(err.Error(), "bad output")
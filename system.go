@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"net/http"
@@ -8,10 +9,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 
 	"github.com/Sirupsen/logrus"
-	"github.com/justone/archiver"
 	"github.com/pkg/errors"
 )
 
@@ -24,7 +25,9 @@ type System interface {
 	MakeExecutable(string) error
 	Stderrf(string, ...interface{})
 	Stdoutf(string, ...interface{})
+	Confirm(string) (bool, error)
 	UnpackArchive(string, string) error
+	UnpackArchiveWithOptions(string, string, ArchiveOptions) error
 	Getenv(string) string
 	DataPath() (string, error)
 }
@@ -66,21 +69,33 @@ func (ds DefaultSystem) Stdoutf(message string, args ...interface{}) {
 	fmt.Fprintf(os.Stdout, message, args...)
 }
 
-func (ds DefaultSystem) UnpackArchive(archive, destPath string) error {
-	unpackSuccess := false
-	for _, format := range archiver.SupportedFormats {
-		if format.Match(archive) {
-			err := format.Open(archive, destPath)
-			if err != nil {
-				return errors.Wrap(err, "error unpacking archive")
-			}
-			unpackSuccess = true
-			break
-		}
+// Confirm asks the user a yes or no question on the terminal.  An error is
+// returned if holen isn't being run interactively.
+func (ds DefaultSystem) Confirm(message string) (bool, error) {
+	stat, err := os.Stdin.Stat()
+	if err != nil || stat.Mode()&os.ModeCharDevice == 0 {
+		return false, fmt.Errorf("not running interactively")
 	}
 
-	if !unpackSuccess {
-		return fmt.Errorf("archive format not supported")
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", message)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, errors.Wrap(err, "unable to read answer")
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+func (ds DefaultSystem) UnpackArchive(archive, destPath string) error {
+	return ds.UnpackArchiveWithOptions(archive, destPath, ArchiveOptions{})
+}
+
+func (ds DefaultSystem) UnpackArchiveWithOptions(archive, destPath string, options ArchiveOptions) error {
+	err := unpackArchive(archive, destPath, options)
+	if err != nil {
+		return errors.Wrap(err, "error unpacking archive")
 	}
 
 	return nil
@@ -128,7 +143,7 @@ func (ll LogrusLogger) Warnf(str string, args ...interface{}) {
 
 type Downloader interface {
 	DownloadFile(string, string) error
-	PullDockerImage(string) error
+	PullDockerImage(string, string) error
 }
 
 type DefaultDownloader struct {
@@ -159,16 +174,20 @@ func (dd DefaultDownloader) DownloadFile(url, path string) error {
 	return nil
 }
 
-func (dd DefaultDownloader) PullDockerImage(image string) error {
-	return dd.RunCommand("docker", []string{"pull", image})
+func (dd DefaultDownloader) PullDockerImage(containerRuntime, image string) error {
+	return dd.RunCommand(containerRuntime, []string{"pull", image})
 }
 
 type Runner interface {
 	RunCommand(string, []string) error
+	RunCommandWithEnv(string, []string, []string) error
 	ExecCommand(string, []string) error
 	ExecCommandWithEnv(string, []string, []string) error
 	CheckCommand(string, []string) bool
+	CommandOutput(string, []string) (string, error)
+	CommandOutputWithInput(string, []string, string) (string, error)
 	CommandOutputToFile(string, []string, string) error
+	RunCommandInDir(string, string, []string, string) error
 }
 
 type DefaultRunner struct {
@@ -182,11 +201,17 @@ func (dr DefaultRunner) CheckCommand(command string, args []string) bool {
 }
 
 func (dr DefaultRunner) RunCommand(command string, args []string) error {
+	return dr.RunCommandWithEnv(command, args, make([]string, 0))
+}
+
+func (dr DefaultRunner) RunCommandWithEnv(command string, args []string, extraEnv []string) error {
+	dr.Debugf("Running command %s with args %v and extra env %v", command, args, extraEnv)
 
 	cmd := exec.Command(command, args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	cmd.Env = mergeEnv(os.Environ(), extraEnv)
 
 	return cmd.Run()
 }
@@ -213,11 +238,42 @@ func (dr DefaultRunner) ExecCommandWithEnv(command string, args []string, extraE
 		if err != nil {
 			return err
 		}
-		return syscall.Exec(fullPath, append([]string{filepath.Base(command)}, args...), append(os.Environ(), extraEnv...))
+		return syscall.Exec(fullPath, append([]string{filepath.Base(command)}, args...), mergeEnv(os.Environ(), extraEnv))
 		// end adapted from
 	}
 }
 
+func (dr DefaultRunner) CommandOutput(command string, args []string) (string, error) {
+	dr.Debugf("Capturing output of command %s with args %v", command, args)
+
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// CommandOutputWithInput is like CommandOutput, but with input written to
+// the command's stdin.
+func (dr DefaultRunner) CommandOutputWithInput(command string, args []string, input string) (string, error) {
+	dr.Debugf("Capturing output of command %s with args %v and input", command, args)
+
+	cmd := exec.Command(command, args...)
+	cmd.Stdin = strings.NewReader(input)
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
 func (dr DefaultRunner) CommandOutputToFile(command string, args []string, outputFile string) error {
 
 	file, err := os.Create(outputFile)
@@ -233,3 +289,21 @@ func (dr DefaultRunner) CommandOutputToFile(command string, args []string, outpu
 
 	return cmd.Run()
 }
+
+// RunCommandInDir runs a command in dir with its output appended to logFile.
+func (dr DefaultRunner) RunCommandInDir(dir, command string, args []string, logFile string) error {
+	dr.Debugf("Running command %s with args %v in %s", command, args, dir)
+
+	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Stdout = file
+	cmd.Stderr = file
+
+	return cmd.Run()
+}
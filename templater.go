@@ -12,6 +12,9 @@ type Templater struct {
 	Arch       string
 	OSArch     string
 	OSArchData map[string]string
+
+	// Root is the directory that a whole unpacked archive is kept in.
+	Root string
 }
 
 // Template takes an input string and templates it with the data contained in
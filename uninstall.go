@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+// UninstallCommand specifies options for the uninstall subcommand.
+type UninstallCommand struct {
+	Version string `short:"v" long:"version" description:"Only uninstall this version of the utility."`
+	DryRun  bool   `short:"n" long:"dry-run" description:"Show what would be removed without removing it"`
+	Args    struct {
+		Name string `description:"utility name" positional-arg-name:"<name>"`
+	} `positional-args:"yes" required:"yes"`
+}
+
+var uninstallCommand UninstallCommand
+
+// Uninstalling utilities
+func (x *UninstallCommand) Execute(args []string) error {
+	inventory, err := NewInventory()
+	if err != nil {
+		return err
+	}
+
+	return inventory.Uninstall(NameVer{uninstallCommand.Args.Name, uninstallCommand.Version}, uninstallCommand.DryRun)
+}
+
+// NewInventory returns an inventory of what's installed on this system.
+func NewInventory() (*Inventory, error) {
+	system := &DefaultSystem{}
+	conf, err := NewDefaultConfigClient(system)
+	if err != nil {
+		return nil, err
+	}
+
+	logger := &LogrusLogger{}
+	return &Inventory{
+		Logger:       logger,
+		ConfigGetter: conf,
+		System:       system,
+		Runner:       &DefaultRunner{logger},
+	}, nil
+}
+
+func init() {
+	_, err := parser.AddCommand("uninstall",
+		"Remove installed versions of a utility.",
+		"",
+		&uninstallCommand)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+}
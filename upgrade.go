@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	goversion "github.com/hashicorp/go-version"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// OutdatedUtility is a linked utility with a newer version in its manifest
+// than any that's installed.
+type OutdatedUtility struct {
+	Name    string
+	Current string
+	Latest  string
+	Held    bool
+	Pinned  bool
+}
+
+// UtilityLinks describes how a utility is linked in the link bin path.
+type UtilityLinks struct {
+	// Type is the link type that the link without a version was made
+	// with, or empty if there isn't one.
+	Type string
+
+	// Pinned is the version that the link without a version always runs,
+	// if it was made to run just one.
+	Pinned string
+
+	// Versions are the versions with a link of their own.
+	Versions []string
+}
+
+// Upgrader finds linked utilities that have newer versions available and
+// fetches them.
+type Upgrader struct {
+	Logger
+	ConfigGetter
+	System
+	Runner
+	Downloader
+	Finder    *DefaultManifestFinder
+	Inventory *Inventory
+}
+
+// compareVersions orders two versions, falling back to comparing them as
+// strings when they can't be parsed.
+func compareVersions(a, b string) int {
+	versionA, errA := goversion.NewVersion(a)
+	versionB, errB := goversion.NewVersion(b)
+	if errA == nil && errB == nil {
+		return versionA.Compare(versionB)
+	}
+
+	return strings.Compare(a, b)
+}
+
+// newestVersion returns the newest of versions.
+func newestVersion(versions []string) string {
+	newest := ""
+	for _, version := range versions {
+		if len(newest) == 0 || compareVersions(version, newest) > 0 {
+			newest = version
+		}
+	}
+
+	return newest
+}
+
+// held reports whether upgrades of a utility have been held back with
+// "holen config hold.<name> true".
+func (u Upgrader) held(name string) bool {
+	hold, err := u.Get(fmt.Sprintf("hold.%s", name))
+	return err == nil && len(hold) > 0 && hold != "false"
+}
+
+// Links returns how each utility is linked in the link bin path, going by
+// their manifests so that provided commands count towards the utility that
+// provides them.
+func (u Upgrader) Links() (map[string]*UtilityLinks, error) {
+	binPath, err := homedir.Expand(u.Finder.DefaultLinkBinPath())
+	if err != nil {
+		return nil, err
+	}
+
+	links, _ := ioutil.ReadDir(binPath)
+	utilities := make(map[string]*UtilityLinks)
+	utilityNames := make(map[string]string)
+	for _, link := range links {
+		linkPath := filepath.Join(binPath, link.Name())
+		if !isHolenLink(linkPath) {
+			continue
+		}
+
+		// each version has its own link, so only look up the manifest once
+		nameVer := ParseName(link.Name())
+		name, found := utilityNames[nameVer.Name]
+		if !found {
+			manifest, err := u.Finder.Find(NameVer{nameVer.Name, ""})
+			if err != nil {
+				u.Debugf("skipping link %s: %s", link.Name(), err)
+			} else {
+				name = manifest.Data.Name
+			}
+			utilityNames[nameVer.Name] = name
+		}
+		if len(name) == 0 {
+			continue
+		}
+
+		utility, ok := utilities[name]
+		if !ok {
+			utility = &UtilityLinks{}
+			utilities[name] = utility
+		}
+
+		if len(nameVer.Version) > 0 {
+			utility.Versions = uniqueStrings(append(utility.Versions, nameVer.Version))
+		} else if len(utility.Type) == 0 || nameVer.Name == name {
+			utility.Type, utility.Pinned = holenLinkType(linkPath)
+		}
+	}
+
+	return utilities, nil
+}
+
+// LinkedUtilities returns the names of the utilities that have a link
+// without a version in the link bin path.
+func (u Upgrader) LinkedUtilities() ([]string, error) {
+	utilities, err := u.Links()
+	if err != nil {
+		return nil, err
+	}
+
+	return linkedNames(utilities), nil
+}
+
+func linkedNames(utilities map[string]*UtilityLinks) []string {
+	names := []string{}
+	for name, links := range utilities {
+		if len(links.Type) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Outdated compares the newest installed version of each utility with the
+// newest version in its manifest.  Utilities without anything installed
+// are left out, since running them gets the newest version anyway.
+// Utilities that are only linked by version, or whose link always runs one
+// version, are pinned there, and compared from the version they're pinned
+// at.
+func (u Upgrader) Outdated(names []string) ([]OutdatedUtility, error) {
+	links, err := u.Links()
+	if err != nil {
+		return nil, err
+	}
+
+	return u.outdated(names, links)
+}
+
+func (u Upgrader) outdated(names []string, links map[string]*UtilityLinks) ([]OutdatedUtility, error) {
+	installed, err := u.Inventory.Find()
+	if err != nil {
+		return nil, err
+	}
+
+	installedVersions := make(map[string][]string)
+	for _, iv := range installed {
+		installedVersions[iv.Name] = append(installedVersions[iv.Name], iv.Version)
+	}
+
+	outdated := []OutdatedUtility{}
+	for _, name := range names {
+		manifest, err := u.Finder.Find(NameVer{name, ""})
+		if err != nil {
+			return nil, err
+		}
+
+		manifestVersions := []string{}
+		for version := range manifest.Data.VersionStrategies() {
+			manifestVersions = append(manifestVersions, version)
+		}
+
+		current := newestVersion(installedVersions[manifest.Data.Name])
+		pinned := false
+		if utility, ok := links[manifest.Data.Name]; ok {
+			if len(utility.Pinned) > 0 {
+				current, pinned = utility.Pinned, true
+			} else if len(utility.Type) == 0 && len(utility.Versions) > 0 {
+				pinned = true
+			}
+		}
+
+		latest := newestVersion(manifestVersions)
+		if len(current) == 0 || len(latest) == 0 {
+			u.Debugf("no installed version of %s to compare", manifest.Data.Name)
+			continue
+		}
+
+		if compareVersions(latest, current) > 0 {
+			outdated = append(outdated, OutdatedUtility{manifest.Data.Name, current, latest, u.held(manifest.Data.Name), pinned})
+		}
+	}
+
+	return outdated, nil
+}
+
+// ShowOutdated lists the linked utilities that have newer versions.
+func (u Upgrader) ShowOutdated() error {
+	links, err := u.Links()
+	if err != nil {
+		return err
+	}
+
+	outdated, err := u.outdated(linkedNames(links), links)
+	if err != nil {
+		return err
+	}
+
+	if len(outdated) == 0 {
+		u.Stdoutf("All linked utilities are up to date.\n")
+		return nil
+	}
+
+	var table bytes.Buffer
+	writer := tabwriter.NewWriter(&table, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "NAME\tCURRENT\tLATEST\t")
+	for _, utility := range outdated {
+		note := ""
+		if utility.Held {
+			note = "held"
+		} else if utility.Pinned {
+			note = "pinned"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", utility.Name, utility.Current, utility.Latest, note)
+	}
+	writer.Flush()
+
+	u.Stdoutf("%s", table.String())
+	return nil
+}
+
+// Upgrade fetches the newest version of the named utility, or of every
+// linked utility if name is empty, and links it again.
+func (u Upgrader) Upgrade(name string) error {
+	links, err := u.Links()
+	if err != nil {
+		return err
+	}
+	linked := linkedNames(links)
+
+	names := linked
+	if len(name) > 0 {
+		manifest, err := u.Finder.Find(NameVer{name, ""})
+		if err != nil {
+			return err
+		}
+		names = []string{manifest.Data.Name}
+	}
+
+	outdated, err := u.outdated(names, links)
+	if err != nil {
+		return err
+	}
+
+	if len(outdated) == 0 {
+		if len(name) > 0 {
+			u.Stdoutf("%s is up to date.\n", names[0])
+		} else {
+			u.Stdoutf("All linked utilities are up to date.\n")
+		}
+		return nil
+	}
+
+	for _, utility := range outdated {
+		if utility.Held {
+			u.Stdoutf("Not upgrading %s, it's held at %s.\n", utility.Name, utility.Current)
+			continue
+		}
+		if utility.Pinned {
+			u.Stdoutf("Not upgrading %s, it's linked pinned at %s.\n", utility.Name, utility.Current)
+			continue
+		}
+
+		err = u.fetch(NameVer{utility.Name, utility.Latest})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("unable to upgrade %s", utility.Name))
+		}
+
+		// link it again the same way it was linked before
+		if containsString(linked, utility.Name) {
+			err = u.Finder.LinkSingleUtility(links[utility.Name].Type, utility.Name, "", "", false)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("unable to link %s", utility.Name))
+			}
+		}
+
+		u.Stdoutf("Upgraded %s from %s to %s.\n", utility.Name, utility.Current, utility.Latest)
+	}
+
+	return nil
+}
+
+// fetch installs a version with the first strategy that's able to, without
+// running it.  Strategies that have nothing to install, like system, are
+// passed over.
+func (u Upgrader) fetch(utility NameVer) error {
+	manifest, err := u.Finder.Find(utility)
+	if err != nil {
+		return err
+	}
+	manifest.Runner = u.Runner
+	manifest.Downloader = u.Downloader
+
+	strategies, err := manifest.LoadStrategies(utility)
+	if err != nil {
+		return err
+	}
+
+	for _, strategy := range strategies {
+		installer, ok := strategy.(Installer)
+		if !ok {
+			continue
+		}
+
+		_, err = installer.Install()
+		if err == nil {
+			return nil
+		}
+		if _, skip := err.(*SkipError); !skip {
+			return err
+		}
+	}
+
+	return fmt.Errorf("no strategy was able to install %s %s", utility.Name, utility.Version)
+}
+
+// NewUpgrader returns an upgrader for the utilities linked on this system.
+func NewUpgrader() (*Upgrader, error) {
+	manifestFinder, err := NewManifestFinder(true)
+	if err != nil {
+		return nil, err
+	}
+
+	inventory, err := NewInventory()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Upgrader{
+		Logger:       inventory.Logger,
+		ConfigGetter: inventory.ConfigGetter,
+		System:       inventory.System,
+		Runner:       inventory.Runner,
+		Downloader:   &DefaultDownloader{inventory.Logger, inventory.Runner},
+		Finder:       manifestFinder,
+		Inventory:    inventory,
+	}, nil
+}
+
+// OutdatedCommand specifies options for the outdated subcommand.
+type OutdatedCommand struct{}
+
+var outdatedCommand OutdatedCommand
+
+// Listing outdated utilities
+func (x *OutdatedCommand) Execute(args []string) error {
+	upgrader, err := NewUpgrader()
+	if err != nil {
+		return err
+	}
+
+	return upgrader.ShowOutdated()
+}
+
+// UpgradeCommand specifies options for the upgrade subcommand.
+type UpgradeCommand struct {
+	Args struct {
+		Name string `description:"utility name" positional-arg-name:"<name>"`
+	} `positional-args:"yes"`
+}
+
+var upgradeCommand UpgradeCommand
+
+// Upgrading utilities
+func (x *UpgradeCommand) Execute(args []string) error {
+	upgrader, err := NewUpgrader()
+	if err != nil {
+		return err
+	}
+
+	return upgrader.Upgrade(upgradeCommand.Args.Name)
+}
+
+func init() {
+	_, err := parser.AddCommand("outdated",
+		"List linked utilities that have newer versions.",
+		"",
+		&outdatedCommand)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	_, err = parser.AddCommand("upgrade",
+		"Fetch the newest versions of linked utilities.",
+		"",
+		&upgradeCommand)
+
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestUpgrader(tempdir string) (*TestManifestUtils, *MemRunner, *MemDownloader, *Upgrader) {
+	wd, _ := os.Getwd()
+	manifestsPath := path.Join(wd, "testdata", "upgrade", "manifests")
+
+	tu, manifestFinder := newTestManifestFinder("")
+	tu.MemSourcePather.TestPaths = []string{manifestsPath}
+	tu.MemSystem.Setenv("XDG_DATA_HOME", path.Join(tempdir, "data"))
+	tu.MemSystem.Setenv("HLN_LINK_BIN_PATH", path.Join(tempdir, "bin"))
+	for _, name := range []string{"jq", "tool", "fresh", "systool"} {
+		tu.MemSystem.Files[path.Join(manifestsPath, name+".yaml")] = true
+	}
+
+	runner := &MemRunner{}
+	downloader := &MemDownloader{}
+	inventory := &Inventory{
+		Logger:       tu.MemLogger,
+		ConfigGetter: tu.MemConfig,
+		System:       tu.MemSystem,
+		Runner:       runner,
+	}
+
+	return tu, runner, downloader, &Upgrader{
+		Logger:       tu.MemLogger,
+		ConfigGetter: tu.MemConfig,
+		System:       tu.MemSystem,
+		Runner:       runner,
+		Downloader:   downloader,
+		Finder:       manifestFinder,
+		Inventory:    inventory,
+	}
+}
+
+// upgradeFixtures installs an older version of jq and tool and the newest
+// fresh, with all three linked.
+func upgradeFixtures(tempdir string) {
+	binPath := path.Join(tempdir, "data", "holen", "bin")
+	linkPath := path.Join(tempdir, "bin")
+	os.MkdirAll(binPath, 0755)
+	os.MkdirAll(linkPath, 0755)
+
+	for _, installed := range []string{"jq--1.5", "tool--2.0", "fresh--1.0"} {
+		ioutil.WriteFile(path.Join(binPath, installed), []byte("binary"), 0755)
+	}
+	for _, name := range []string{"jq", "tool", "fresh"} {
+		ioutil.WriteFile(path.Join(linkPath, name), []byte("#!/bin/sh\nexec holen run "+name+" -- \"$@\"\n"), 0755)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(1, compareVersions("1.10", "1.9"))
+	assert.Equal(-1, compareVersions("1.5", "1.6"))
+	assert.Equal(0, compareVersions("1.6", "1.6.0"))
+	assert.Equal(1, compareVersions("nightly", "latest"))
+	assert.Equal("1.10", newestVersion([]string{"1.9", "1.10", "1.2"}))
+	assert.Equal("", newestVersion(nil))
+}
+
+func TestOutdated(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	upgradeFixtures(tempdir)
+
+	tu, _, _, upgrader := newTestUpgrader(tempdir)
+	tu.MemConfig.Set(false, "hold.tool", "true")
+
+	linked, err := upgrader.LinkedUtilities()
+	assert.Nil(err)
+	assert.Equal([]string{"fresh", "jq", "tool"}, linked)
+
+	assert.Nil(upgrader.ShowOutdated())
+	assert.Equal([]string{"" +
+		"NAME  CURRENT  LATEST  \n" +
+		"jq    1.5      1.6     \n" +
+		"tool  2.0      2.1     held\n",
+	}, tu.MemSystem.StdoutMessages)
+
+	// nothing installed, nothing to compare
+	os.RemoveAll(path.Join(tempdir, "data"))
+	tu, _, _, upgrader = newTestUpgrader(tempdir)
+	assert.Nil(upgrader.ShowOutdated())
+	assert.Equal([]string{"All linked utilities are up to date.\n"}, tu.MemSystem.StdoutMessages)
+}
+
+func TestUpgrade(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	upgradeFixtures(tempdir)
+
+	tu, _, downloader, upgrader := newTestUpgrader(tempdir)
+	tu.MemConfig.Set(false, "hold.tool", "true")
+
+	assert.Nil(upgrader.Upgrade(""))
+	assert.Equal([]string{
+		"Upgraded jq from 1.5 to 1.6.\n",
+		"Not upgrading tool, it's held at 2.0.\n",
+	}, tu.MemSystem.StdoutMessages)
+	assert.Len(downloader.Files, 1)
+	assert.Contains(downloader.Files, "https://example.com/jq-1.6")
+
+	_, err := os.Stat(path.Join(tempdir, "data", "holen", "bin", "jq--1.6"))
+	assert.Nil(err)
+	_, err = os.Stat(path.Join(tempdir, "bin", "jq--1.6"))
+	assert.Nil(err)
+
+	// now it's up to date
+	tu, _, downloader, upgrader = newTestUpgrader(tempdir)
+	assert.Nil(upgrader.Upgrade("jq"))
+	assert.Equal([]string{"jq is up to date.\n"}, tu.MemSystem.StdoutMessages)
+	assert.Empty(downloader.Files)
+
+	// unless it's held, naming it upgrades it
+	tu, _, downloader, upgrader = newTestUpgrader(tempdir)
+	assert.Nil(upgrader.Upgrade("tool"))
+	assert.Equal([]string{"Upgraded tool from 2.0 to 2.1.\n"}, tu.MemSystem.StdoutMessages)
+	assert.Len(downloader.Files, 1)
+	assert.Contains(downloader.Files, "https://example.com/tool-2.1")
+
+	_, err = upgrader.Finder.Find(NameVer{"missing", ""})
+	assert.NotNil(err)
+	assert.NotNil(upgrader.Upgrade("missing"))
+}
+
+func TestUpgradeSystemFirst(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+
+	binPath := path.Join(tempdir, "data", "holen", "bin")
+	os.MkdirAll(binPath, 0755)
+	ioutil.WriteFile(path.Join(binPath, "systool--3.0"), []byte("binary"), 0755)
+
+	// system comes first, but has nothing to install
+	tu, _, downloader, upgrader := newTestUpgrader(tempdir)
+	assert.Nil(upgrader.Upgrade("systool"))
+	assert.Equal([]string{"Upgraded systool from 3.0 to 3.1.\n"}, tu.MemSystem.StdoutMessages)
+	assert.Contains(downloader.Files, "https://example.com/systool-3.1")
+
+	_, err := os.Stat(path.Join(binPath, "systool--3.1"))
+	assert.Nil(err)
+
+	// when nothing is able to install it, it isn't reported as upgraded
+	tu, _, _, upgrader = newTestUpgrader(tempdir)
+	tu.MemConfig.Set(false, "strategy.systool.xpriority", "system")
+	os.Remove(path.Join(binPath, "systool--3.1"))
+
+	err = upgrader.Upgrade("systool")
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "no strategy was able to install systool 3.1")
+	assert.Empty(tu.MemSystem.StdoutMessages)
+}
+
+func TestUpgradePinned(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	upgradeFixtures(tempdir)
+
+	// jq always runs 1.5, tool is only linked by version
+	linkPath := path.Join(tempdir, "bin")
+	ioutil.WriteFile(path.Join(linkPath, "jq"), []byte("#!/bin/sh\nexec holen run --version 1.5 jq -- \"$@\"\n"), 0755)
+	os.Remove(path.Join(linkPath, "tool"))
+	ioutil.WriteFile(path.Join(linkPath, "tool--2.0"), []byte("#!/bin/sh\nexec holen run --version 2.0 tool -- \"$@\"\n"), 0755)
+
+	tu, _, _, upgrader := newTestUpgrader(tempdir)
+	links, err := upgrader.Links()
+	assert.Nil(err)
+	assert.Equal(&UtilityLinks{"script", "1.5", nil}, links["jq"])
+	assert.Equal(&UtilityLinks{"", "", []string{"2.0"}}, links["tool"])
+
+	outdated, err := upgrader.outdated([]string{"jq", "tool"}, links)
+	assert.Nil(err)
+	assert.Equal([]OutdatedUtility{
+		{"jq", "1.5", "1.6", false, true},
+		{"tool", "2.0", "2.1", false, true},
+	}, outdated)
+
+	assert.Nil(upgrader.ShowOutdated())
+	assert.Equal([]string{"" +
+		"NAME  CURRENT  LATEST  \n" +
+		"jq    1.5      1.6     pinned\n",
+	}, tu.MemSystem.StdoutMessages)
+
+	tu, _, downloader, upgrader := newTestUpgrader(tempdir)
+	assert.Nil(upgrader.Upgrade("jq"))
+	assert.Equal([]string{"Not upgrading jq, it's linked pinned at 1.5.\n"}, tu.MemSystem.StdoutMessages)
+	assert.Empty(downloader.Files)
+
+	tu, _, downloader, upgrader = newTestUpgrader(tempdir)
+	assert.Nil(upgrader.Upgrade("tool"))
+	assert.Equal([]string{"Not upgrading tool, it's linked pinned at 2.0.\n"}, tu.MemSystem.StdoutMessages)
+	assert.Empty(downloader.Files)
+}
+
+func TestUpgradeKeepsLinkType(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "holen")
+	defer os.RemoveAll(tempdir)
+	upgradeFixtures(tempdir)
+
+	selfPath := path.Join(tempdir, "holen")
+	ioutil.WriteFile(selfPath, []byte("binary"), 0755)
+	jqLink := path.Join(tempdir, "bin", "jq")
+	os.Remove(jqLink)
+	os.Symlink(selfPath, jqLink)
+
+	tu, _, _, upgrader := newTestUpgrader(tempdir)
+	upgrader.Finder.SelfPath = selfPath
+
+	assert.Nil(upgrader.Upgrade("jq"))
+	assert.Equal([]string{"Upgraded jq from 1.5 to 1.6.\n"}, tu.MemSystem.StdoutMessages)
+
+	for _, link := range []string{"jq", "jq--1.6"} {
+		target, err := os.Readlink(path.Join(tempdir, "bin", link))
+		assert.Nil(err)
+		assert.Equal(selfPath, target)
+	}
+}
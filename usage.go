@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// UsageRecord is kept for each version of a utility that's been run.  The
+// modification time of the file is when it was last run.  Docker runs also
+// note the image, since it can't be found on disk afterwards.
+type UsageRecord struct {
+	Runtime string `yaml:"runtime,omitempty"`
+	Image   string `yaml:"image,omitempty"`
+}
+
+// usagePath returns where the usage record for a version of a utility is
+// kept.
+func usagePath(system System, name, version string) (string, error) {
+	holenPath, err := system.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "usage", fmt.Sprintf("%s--%s", name, version)), nil
+}
+
+// RecordUsage notes that a version of a utility is being run.  An existing
+// record is only touched, unless there's a docker image to remember.
+func (sc *StrategyCommon) RecordUsage(name, version, runtime, image string) {
+	if len(version) == 0 {
+		return
+	}
+
+	recordPath, err := usagePath(sc.System, name, version)
+	if err != nil {
+		sc.Debugf("unable to record usage: %s", err)
+		return
+	}
+
+	now := time.Now()
+	if len(image) == 0 && os.Chtimes(recordPath, now, now) == nil {
+		return
+	}
+
+	data := []byte{}
+	if len(image) > 0 {
+		data, _ = yaml.Marshal(UsageRecord{runtime, image})
+	}
+
+	os.MkdirAll(filepath.Dir(recordPath), 0755)
+	err = ioutil.WriteFile(recordPath, data, 0644)
+	if err != nil {
+		sc.Debugf("unable to record usage: %s", err)
+	}
+}
+
+// readUsage returns the usage record for a version of a utility and when it
+// was last run, which is zero if it never was.
+func readUsage(system System, name, version string) (UsageRecord, time.Time) {
+	var record UsageRecord
+
+	recordPath, err := usagePath(system, name, version)
+	if err != nil {
+		return record, time.Time{}
+	}
+
+	info, err := os.Stat(recordPath)
+	if err != nil {
+		return record, time.Time{}
+	}
+
+	data, err := ioutil.ReadFile(recordPath)
+	if err == nil {
+		yaml.Unmarshal(data, &record)
+	}
+
+	return record, info.ModTime()
+}
+
+// downloadRecordPath returns where holen notes that it put fileName in the
+// download path, which may be shared with files it didn't put there.
+func downloadRecordPath(system System, fileName string) (string, error) {
+	holenPath, err := system.DataPath()
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get holen data path")
+	}
+
+	return filepath.Join(holenPath, "downloads", fileName), nil
+}
+
+// RecordDownload notes that localPath was installed into the download path.
+func (sc *StrategyCommon) RecordDownload(localPath string) {
+	recordPath, err := downloadRecordPath(sc.System, filepath.Base(localPath))
+	if err != nil {
+		sc.Debugf("unable to record download: %s", err)
+		return
+	}
+
+	os.MkdirAll(filepath.Dir(recordPath), 0755)
+	err = ioutil.WriteFile(recordPath, []byte{}, 0644)
+	if err != nil {
+		sc.Debugf("unable to record download: %s", err)
+	}
+}
+
+// downloaded reports whether holen put fileName in the download path.
+func downloaded(system System, fileName string) bool {
+	recordPath, err := downloadRecordPath(system, fileName)
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(recordPath)
+	return err == nil
+}
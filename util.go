@@ -8,6 +8,9 @@ import (
 	"hash"
 	"io"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/kardianos/osext"
@@ -75,6 +78,151 @@ func mergeMaps(m1, m2 map[interface{}]interface{}) map[interface{}]interface{} {
 	return m1
 }
 
+// stringSlice converts a list read from a manifest into strings, so that
+// entries like port numbers don't need to be quoted.
+func stringSlice(raw interface{}) []string {
+	strs := []string{}
+	if list, ok := raw.([]interface{}); ok {
+		for _, item := range list {
+			strs = append(strs, fmt.Sprintf("%v", item))
+		}
+	}
+
+	return strs
+}
+
+// stringMap converts a map read from a manifest into strings.
+func stringMap(raw interface{}) map[string]string {
+	strs := make(map[string]string)
+	if rawMap, ok := raw.(map[interface{}]interface{}); ok {
+		for key, value := range rawMap {
+			strs[fmt.Sprintf("%v", key)] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	return strs
+}
+
+// parseSize reads a size in bytes, optionally with a K, M, G or T suffix
+// (powers of 1024), like "500M".
+func parseSize(size string) (int64, error) {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	size = strings.TrimSuffix(strings.TrimSuffix(size, "B"), "I")
+
+	multiplier := int64(1)
+	if len(size) > 0 {
+		if index := strings.IndexByte("KMGT", size[len(size)-1]); index >= 0 {
+			multiplier = 1 << (10 * uint(index+1))
+			size = size[:len(size)-1]
+		}
+	}
+
+	value, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid size")
+	}
+
+	return value * multiplier, nil
+}
+
+// formatSize shows a size in bytes the way parseSize reads it, rounded to
+// one decimal place.
+func formatSize(size int64) string {
+	if size < 1024 {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	value := float64(size)
+	for _, unit := range []string{"K", "M", "G"} {
+		value /= 1024
+		if value < 1024 {
+			return fmt.Sprintf("%.1f%s", value, unit)
+		}
+	}
+
+	return fmt.Sprintf("%.1fT", value/1024)
+}
+
+// mergeEnv returns env with the variables in extra added, replacing any
+// that are already set rather than leaving duplicates behind.
+func mergeEnv(env, extra []string) []string {
+	replaced := make(map[string]bool)
+	for _, kv := range extra {
+		replaced[strings.SplitN(kv, "=", 2)[0]] = true
+	}
+
+	merged := []string{}
+	for _, kv := range env {
+		if !replaced[strings.SplitN(kv, "=", 2)[0]] {
+			merged = append(merged, kv)
+		}
+	}
+
+	return append(merged, extra...)
+}
+
+// uniqueStrings returns strs with duplicates removed, keeping the first
+// occurrence of each.
+func uniqueStrings(strs []string) []string {
+	seen := make(map[string]bool)
+	unique := []string{}
+	for _, str := range strs {
+		if !seen[str] {
+			seen[str] = true
+			unique = append(unique, str)
+		}
+	}
+
+	return unique
+}
+
+// containsString reports whether str is one of strs.
+func containsString(strs []string, str string) bool {
+	for _, candidate := range strs {
+		if candidate == str {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lookPath returns every executable called name in the directories of
+// pathEnv, in order.  A name with a directory in it is only checked itself.
+func lookPath(pathEnv, name string) []string {
+	candidates := []string{name}
+	if !strings.ContainsRune(name, filepath.Separator) {
+		candidates = []string{}
+		for _, dir := range filepath.SplitList(pathEnv) {
+			if len(dir) > 0 {
+				candidates = append(candidates, filepath.Join(dir, name))
+			}
+		}
+	}
+
+	found := []string{}
+	for _, candidate := range candidates {
+		fileStat, err := os.Stat(candidate)
+		if err == nil && fileStat.Mode().IsRegular() && fileStat.Mode()&0111 != 0 {
+			found = append(found, candidate)
+		}
+	}
+
+	return found
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes str for a POSIX shell, leaving it alone if it doesn't
+// need it.
+func shellQuote(str string) string {
+	if shellSafe.MatchString(str) {
+		return str
+	}
+
+	return fmt.Sprintf("'%s'", strings.Replace(str, "'", `'\''`, -1))
+}
+
 func hashFile(algo, filePath string) (string, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
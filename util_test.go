@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -207,3 +208,107 @@ func TestHashFile(t *testing.T) {
 	}
 
 }
+
+func TestStringSlice(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{}, stringSlice(nil))
+	assert.Equal([]string{}, stringSlice("not a list"))
+	assert.Equal([]string{"8888", "80:80", "true"}, stringSlice([]interface{}{8888, "80:80", true}))
+}
+
+func TestUniqueStrings(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{}, uniqueStrings(nil))
+	assert.Equal([]string{"b", "a", "c"}, uniqueStrings([]string{"b", "a", "b", "c", "a"}))
+}
+
+func TestMergeEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal([]string{"HOME=/home/user"}, mergeEnv([]string{"HOME=/home/user"}, nil))
+	assert.Equal(
+		[]string{"HOME=/home/user", "PATH=/opt/bin:/usr/bin", "EMPTY="},
+		mergeEnv([]string{"PATH=/usr/bin", "HOME=/home/user", "EMPTY=x"}, []string{"PATH=/opt/bin:/usr/bin", "EMPTY="}),
+	)
+}
+
+func TestParseSize(t *testing.T) {
+	assert := assert.New(t)
+
+	var sizeTests = []struct {
+		size   string
+		result int64
+	}{
+		{"1024", 1024},
+		{"10K", 10 << 10},
+		{"500M", 500 << 20},
+		{"500mb", 500 << 20},
+		{"2GiB", 2 << 30},
+		{"1T", 1 << 40},
+	}
+
+	for _, test := range sizeTests {
+		result, err := parseSize(test.size)
+		assert.Nil(err)
+		assert.Equal(test.result, result)
+	}
+
+	for _, size := range []string{"", "M", "lots", "-5", "0", "1.5G"} {
+		_, err := parseSize(size)
+		assert.NotNil(err, size)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("0B", formatSize(0))
+	assert.Equal("1023B", formatSize(1023))
+	assert.Equal("1.0K", formatSize(1024))
+	assert.Equal("1.5M", formatSize(3<<19))
+	assert.Equal("4.0G", formatSize(4<<30))
+	assert.Equal("2.0T", formatSize(2<<40))
+}
+
+func TestShellQuote(t *testing.T) {
+	assert := assert.New(t)
+
+	var quoteTests = []struct {
+		str    string
+		result string
+	}{
+		{"simple", "simple"},
+		{"--flag=a,b", "--flag=a,b"},
+		{"", "''"},
+		{"two words", "'two words'"},
+		{"$HOME", "'$HOME'"},
+		{"it's", `'it'\''s'`},
+	}
+
+	for _, test := range quoteTests {
+		assert.Equal(test.result, shellQuote(test.str))
+	}
+}
+
+func TestLookPath(t *testing.T) {
+	assert := assert.New(t)
+
+	tempdir, _ := ioutil.TempDir("", "lookpath")
+	defer os.RemoveAll(tempdir)
+
+	first := path.Join(tempdir, "first")
+	second := path.Join(tempdir, "second")
+	os.MkdirAll(first, 0755)
+	os.MkdirAll(second, 0755)
+	ioutil.WriteFile(path.Join(first, "tool"), []byte{}, 0644)
+	ioutil.WriteFile(path.Join(second, "tool"), []byte{}, 0755)
+	ioutil.WriteFile(path.Join(first, "other"), []byte{}, 0755)
+
+	pathEnv := strings.Join([]string{first, "", second}, string(os.PathListSeparator))
+	assert.Equal([]string{path.Join(second, "tool")}, lookPath(pathEnv, "tool"))
+	assert.Equal([]string{path.Join(first, "other")}, lookPath(pathEnv, "other"))
+	assert.Equal([]string{path.Join(first, "other")}, lookPath("", path.Join(first, "other")))
+	assert.Equal([]string{}, lookPath(pathEnv, "missing"))
+}
@@ -1,21 +1,37 @@
 package main
 
-import "fmt"
-
 type VersionCommand struct {
-	// nothing yet
+	OutputOptions
 }
 
 var versionCommand VersionCommand
 
 var version string
 
+// VersionReport is how the version is shown as JSON or YAML.  Version is
+// empty when holen was compiled from git.
+type VersionReport struct {
+	Version string `json:"version" yaml:"version"`
+}
+
 func (x *VersionCommand) Execute(args []string) error {
+	format, err := versionCommand.Format()
+	if err != nil {
+		return err
+	}
+
+	return showVersion(&DefaultSystem{}, version, format)
+}
+
+func showVersion(system System, version, format string) error {
+	if format != textOutput {
+		return writeStructured(system, format, VersionReport{version})
+	}
 
 	if len(version) == 0 {
-		fmt.Println("unknown version, compiled from git")
+		system.Stdoutf("unknown version, compiled from git\n")
 	} else {
-		fmt.Println("version:", version)
+		system.Stdoutf("version: %s\n", version)
 	}
 
 	return nil